@@ -1,10 +1,7 @@
 package app
 
 import (
-	"github.com/hajimehoshi/ebiten/v2"
 	"image/color"
-	"math"
-	"time"
 )
 
 type Control int
@@ -12,7 +9,7 @@ type Control int
 const (
 	ScreenWidth           = 300
 	ScreenHeight          = 212
-	TimeStep              = 1000 / 60
+	TimeStep              = 1000 / TicksPerSecond
 	TimeStepSec           = float64(TimeStep) / float64(1000)
 	LeftButton    Control = 0
 	RightButton   Control = 1
@@ -21,9 +18,131 @@ const (
 	PauseButton   Control = 4
 	SoundButton   Control = 5
 	CrtButton     Control = 6
-	SkidMillis            = 500
+	P2LeftButton  Control = 7
+	P2RightButton Control = 8
+	P2FlapButton  Control = 9
+	ScrollButton  Control = 10
+	OptionsButton Control = 11
+	SkidTicks             = 30
+	MaxEnemies            = 8
 )
 
+// Durations in simulation ticks
+const (
+	TicksPerSecond  = 60
+	SpawnAnimTicks  = 2
+	AccelTicks      = 8
+	FlapTicks       = 12
+	WaveDelayTicks  = 3 * TicksPerSecond
+	SpawnDelayTicks = TicksPerSecond
+	EggHatchTicks   = 6 * TicksPerSecond
+	EggWobbleTicks  = 2 * TicksPerSecond
+	EggCrackTicks   = TicksPerSecond / 2
+	EggPromoteTicks = 10 * TicksPerSecond // a rider left this long after landing comes back promoted
+	GlideTicks      = 4                   // wings stay down after a flap
+
+	PteroArrivalTicks = 45 * TicksPerSecond
+	PteroSwoopTicks   = 2 * TicksPerSecond
+	PteroMouthCycle   = 48
+	PteroMouthTicks   = 16
+
+	BridgeBurnTicks = 3 // per column of bridge
+	DissolveTicks   = TicksPerSecond
+	TrollRestTicks  = 2 * TicksPerSecond
+)
+
+// The lava pit either side of the bottom cliff
+const (
+	BridgeWave       = 3  // the bridges over the pit burn away at the start of this wave
+	TrollReach       = 24 // how far above the lava the troll can grab a mount
+	TrollRise        = 2.0
+	TrollPull        = 0.4
+	TrollLift        = 3.0
+	TrollEscapeFlaps = 5
+)
+
+// The screens around the game, in ticks where they're durations
+const (
+	TitleTicks        = 10 * TicksPerSecond // on the title before the demo starts
+	AttractTicks      = 40 * TicksPerSecond // longest the demo runs
+	GameOverTicks     = 5 * TicksPerSecond
+	GameOverSkipTicks = TicksPerSecond // before a button cuts the game over short
+	BlinkTicks        = TicksPerSecond / 2
+	HighScores        = 8 // places on each high score table
+	InitialsLength    = 3
+
+	// the title shows each high score table this long
+	BoardTicks = 5 * TicksPerSecond
+)
+
+// The scrolling arena lays the level out side by side across a world this many screens wide
+const ScrollScreens = 3
+
+// Enemies get sharper as the waves go on
+const (
+	AIAggressionPerWave = 0.03
+	AIReactionPerWave   = 2 // ticks
+	AIMinReactionTicks  = 10
+	AILaneReached       = 4 // close enough to a lane to go patrolling another
+)
+
+// Pterodactyl flight
+const (
+	PteroSpeed   = 2.5
+	PteroHunting = 0.6 // chance of swooping into the player's lane
+)
+
+// Points awarded for scoring events
+const (
+	EggPoints         = 250
+	MaxEggPoints      = 1000
+	EggCatchPoints    = 500
+	BounderPoints     = 500
+	HunterPoints      = 750
+	ShadowLordPoints  = 1500
+	PterodactylPoints = 1000
+	SurvivalPoints    = 3000
+	TeamPoints        = 3000
+	GladiatorPoints   = 3000
+	EggWavePoints     = 3000 // for clearing an egg wave before any of the eggs hatch
+	PteroWavePoints   = 3000 // for killing a pterodactyl on a pterodactyl wave
+	ExtraLifePoints   = 20000
+	StartingLives     = 4
+)
+
+// ControlNames are what each control is called in the options and the saved bindings
+var ControlNames = []string{
+	LeftButton:    "left",
+	RightButton:   "right",
+	FlapButton:    "flap",
+	GodModeButton: "god mode",
+	PauseButton:   "pause",
+	SoundButton:   "sound",
+	CrtButton:     "crt",
+	P2LeftButton:  "p2 left",
+	P2RightButton: "p2 right",
+	P2FlapButton:  "p2 flap",
+	ScrollButton:  "scroll",
+	OptionsButton: "options",
+}
+
+func (c Control) String() string {
+	return ControlNames[c]
+}
+
+// Controls are the buttons a player steers their mount with
+type Controls struct {
+	Left  Control
+	Right Control
+	Flap  Control
+}
+
+// PlayerControls is the control mapping for each player, which also caps how many can play
+var PlayerControls = []Controls{
+	{Left: LeftButton, Right: RightButton, Flap: FlapButton},
+	{Left: P2LeftButton, Right: P2RightButton, Flap: P2FlapButton},
+}
+
 var (
 	MoveSpeed = []float64{
 		0,
@@ -32,34 +151,13 @@ var (
 		2.0,
 		2.5,
 	}
-	WalkAnimSpeed = []time.Duration{
-		time.Millisecond * time.Duration(140),
-		time.Millisecond * time.Duration(80),
-		time.Millisecond * time.Duration(40),
-		time.Millisecond * time.Duration(9),
+	// WalkAnimSpeed is the number of ticks between walk frames at each speed
+	WalkAnimSpeed = []int{
+		9,
+		5,
+		3,
+		1,
 	}
-	SpawnPoints = [][]int{
-		{236, 96},  // right
-		{132, 168}, // bottom
-		{116, 53},  // top
-		{16, 104},  // left
-	}
-	Lanes = []int{
-		35,
-		89,
-		159,
-	}
-
-	Controls = map[Control]ebiten.Key{
-		LeftButton:    ebiten.KeyLeft,
-		RightButton:   ebiten.KeyRight,
-		FlapButton:    ebiten.KeySpace,
-		GodModeButton: ebiten.KeyG,
-		PauseButton:   ebiten.KeyP,
-		SoundButton:   ebiten.KeyS,
-		CrtButton:     ebiten.KeyC,
-	}
-
 	White = color.RGBA{
 		R: 255,
 		G: 255,
@@ -86,19 +184,6 @@ var (
 	}
 )
 
-// WrappedDistance Calculates distance between two points on a playfield that wraps around on the x dimension
-// Adapted from:
-// https://blog.demofox.org/2017/10/01/calculating-the-distance-between-points-in-wrap-around-toroidal-space/
-func WrappedDistance(x1, y1, x2, y2 int) float64 {
-	dx := float64(Abs(x2 - x1))
-	if dx > float64(ScreenWidth)/2 {
-		dx = ScreenWidth - dx
-	}
-
-	dy := float64(y2 - y1)
-	return math.Sqrt(dx*dx + dy*dy)
-}
-
 func Abs(x int) int {
 	if x < 0 {
 		return -x
@@ -0,0 +1,21 @@
+package app
+
+type Sound int
+
+const (
+	BumpSound       Sound = 0
+	EggSound        Sound = 1
+	EnergizeSound   Sound = 2
+	FlapDnSound     Sound = 3
+	FlapUpSound     Sound = 4
+	HitSound        Sound = 5
+	OneUpSound      Sound = 6
+	LavaSound       Sound = 7
+	PteroSound      Sound = 8
+	SkidSound       Sound = 9
+	SpawnSound      Sound = 10
+	SpawnEnemySound Sound = 11
+	Walk1Sound      Sound = 12
+	Walk2Sound      Sound = 13
+	WhompSound      Sound = 14
+)
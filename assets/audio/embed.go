@@ -3,30 +3,11 @@ package audio
 import (
 	"bytes"
 	_ "embed"
+	"github.com/depsypher/gojoust/app"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
 )
 
-type Sound int
-
-const (
-	BumpSound       Sound = 0
-	EggSound        Sound = 1
-	EnergizeSound   Sound = 2
-	FlapDnSound     Sound = 3
-	FlapUpSound     Sound = 4
-	HitSound        Sound = 5
-	OneUpSound      Sound = 6
-	LavaSound       Sound = 7
-	PteroSound      Sound = 8
-	SkidSound       Sound = 9
-	SpawnSound      Sound = 10
-	SpawnEnemySound Sound = 11
-	Walk1Sound      Sound = 12
-	Walk2Sound      Sound = 13
-	WhompSound      Sound = 14
-)
-
 var (
 	//go:embed bump.ogg
 	Bump []byte
@@ -73,22 +54,22 @@ var (
 	//go:embed whomp.ogg
 	Whomp []byte
 
-	soundFiles = map[Sound][]byte{
-		BumpSound:       Bump,
-		EggSound:        Egg,
-		EnergizeSound:   Energize,
-		FlapDnSound:     FlapDn,
-		FlapUpSound:     FlapUp,
-		OneUpSound:      OneUp,
-		LavaSound:       Lava,
-		PteroSound:      Ptero,
-		SkidSound:       Skid,
-		SpawnSound:      Spawn,
-		SpawnEnemySound: SpawnEnemy,
-		Walk1Sound:      Walk1,
-		Walk2Sound:      Walk2,
-		HitSound:        Hit,
-		WhompSound:      Whomp,
+	soundFiles = map[app.Sound][]byte{
+		app.BumpSound:       Bump,
+		app.EggSound:        Egg,
+		app.EnergizeSound:   Energize,
+		app.FlapDnSound:     FlapDn,
+		app.FlapUpSound:     FlapUp,
+		app.OneUpSound:      OneUp,
+		app.LavaSound:       Lava,
+		app.PteroSound:      Ptero,
+		app.SkidSound:       Skid,
+		app.SpawnSound:      Spawn,
+		app.SpawnEnemySound: SpawnEnemy,
+		app.Walk1Sound:      Walk1,
+		app.Walk2Sound:      Walk2,
+		app.HitSound:        Hit,
+		app.WhompSound:      Whomp,
 	}
 	audioContext = audio.NewContext(44100)
 )
@@ -97,10 +78,10 @@ type SoundPlayer struct {
 	player *audio.Player
 }
 
-type GameSounds map[Sound]*SoundPlayer
+type GameSounds map[app.Sound]*SoundPlayer
 
 func LoadSounds() (GameSounds, error) {
-	sounds := map[Sound]*SoundPlayer{}
+	sounds := map[app.Sound]*SoundPlayer{}
 	for name, file := range soundFiles {
 		reader := bytes.NewReader(file)
 		decoded, err := vorbis.DecodeWithSampleRate(44100, reader)
@@ -0,0 +1,10 @@
+package levels
+
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed classic.json
+	Classic_json []byte
+)
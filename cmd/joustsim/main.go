@@ -0,0 +1,97 @@
+// Command joustsim runs the game simulation headless, without a window, graphics or audio.
+// It's handy for checking replays and measuring how fast the simulation runs, and with -bench
+// how much memory it allocates as it goes, which once a game is underway should be nothing.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/depsypher/gojoust/replay"
+	"log"
+	"runtime"
+	"time"
+)
+
+var (
+	seed       = flag.Int64("seed", 1, "seed for gameplay randomness")
+	ticks      = flag.Int("ticks", 60*60, "number of ticks to simulate when not playing a replay")
+	replayPath = flag.String("replay", "", "simulate a recorded replay file instead")
+	levelPath  = flag.String("level", "", "simulate the arena from this level file instead of the classic one")
+	bench      = flag.Bool("bench", false, "report the memory allocated per tick")
+	coop       = flag.Bool("coop", false, "simulate a co-op game when not playing a replay")
+)
+
+// benchWarmup is how long -bench lets the first wave get going before it starts counting
+const benchWarmup = 10 * app.TicksPerSecond
+
+func main() {
+	flag.Parse()
+
+	ss, err := entity.LoadSpriteSheet()
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to load embedded spritesheet: %s", err))
+	}
+
+	level := entity.DefaultLevel()
+	if *levelPath != "" {
+		level, err = entity.LoadLevel(*levelPath)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load level: %s", err))
+		}
+	}
+
+	mode := entity.Competitive
+	if *coop {
+		mode = entity.Coop
+	}
+	var playback *replay.Playback
+	if *replayPath != "" {
+		r, err := replay.Load(*replayPath)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load replay: %s", err))
+		}
+		if err := r.CheckLevel(level); err != nil {
+			log.Fatal(err)
+		}
+		*seed = r.Seed
+		mode = r.Mode
+		*ticks = len(r.Ticks)
+		playback = r.Play()
+	}
+
+	gs := entity.NewGameState(ss, level, *seed, mode)
+	if playback != nil {
+		playback.Drive(gs)
+	}
+	var before, after runtime.MemStats
+	warmup := min(benchWarmup, *ticks/2)
+	start := time.Now()
+	for i := 0; i < *ticks; i++ {
+		if *bench && i == warmup {
+			runtime.ReadMemStats(&before)
+		}
+		if playback != nil {
+			playback.Next(gs.Keys)
+		}
+		gs.Update()
+		gs.DrainSounds()
+	}
+	elapsed := time.Since(start)
+	if *bench {
+		runtime.ReadMemStats(&after)
+	}
+
+	fmt.Printf("seed %d: simulated %d ticks in %s (%.0f ticks/sec)\n",
+		*seed, gs.Clock.Now(), elapsed, float64(*ticks)/elapsed.Seconds())
+	for _, p := range gs.Players {
+		fmt.Printf("player %d at %.1f,%.1f scored %d\n", p.Number+1, p.X, p.Y, p.Score)
+	}
+	fmt.Printf("%d buzzards on wave %d\n", len(gs.Buzzards), gs.Wave.Number)
+	if *bench {
+		n := float64(*ticks - warmup)
+		fmt.Printf("%.2f allocations and %.0f bytes per tick after the first %d\n",
+			float64(after.Mallocs-before.Mallocs)/n, float64(after.TotalAlloc-before.TotalAlloc)/n, warmup)
+	}
+}
@@ -0,0 +1,166 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"math"
+	"math/rand"
+)
+
+type AIMode int
+
+const (
+	PATROLLING AIMode = iota
+	HUNTING    AIMode = iota
+	EVADING    AIMode = iota
+)
+
+// Tactics are the knobs for how a class of enemy flies and fights, and what it's worth.
+// Each wave gets its own copy, so difficulty can be tuned wave by wave.
+type Tactics struct {
+	Speed      int     // walking and flying speed, an index into app.MoveSpeed
+	Points     int     // awarded for unhorsing the rider
+	FlapTicks  int     // minimum ticks between flaps
+	Reaction   int     // ticks between decisions about what to do next
+	Aggression float64 // chance of going after a player rather than patrolling a lane
+	Caution    float64 // chance of breaking away from a player that has got above it
+	Climb      float64 // how far above a player it tries to get before diving in
+	Range      float64 // how close a player has to be to be noticed
+}
+
+var classTactics = []Tactics{
+	BOUNDER:     {Speed: 1, Points: app.BounderPoints, FlapTicks: 10, Reaction: 60, Aggression: 0.2, Caution: 0.1, Climb: 4, Range: 100},
+	HUNTER:      {Speed: 2, Points: app.HunterPoints, FlapTicks: 7, Reaction: 40, Aggression: 0.5, Caution: 0.4, Climb: 10, Range: 150},
+	SHADOW_LORD: {Speed: 3, Points: app.ShadowLordPoints, FlapTicks: 4, Reaction: 20, Aggression: 0.8, Caution: 0.7, Climb: 16, Range: 300},
+}
+
+// tacticsFor is how each class of enemy fights on the given wave, sharper the further in
+func tacticsFor(wave int) []Tactics {
+	result := make([]Tactics, len(classTactics))
+	for class, t := range classTactics {
+		t.Aggression = min(1, t.Aggression+app.AIAggressionPerWave*float64(wave-1))
+		t.Reaction = max(app.AIMinReactionTicks, t.Reaction-app.AIReactionPerWave*(wave-1))
+		result[class] = t
+	}
+	return result
+}
+
+// AIController flies a mount for the computer. It patrols the lanes until it notices
+// something to joust, then climbs above it to dive in, breaking away if it gets above it.
+type AIController struct {
+	tactics func(gs *GameState) Tactics
+	// targets appends everything it hunts to result
+	targets func(gs *GameState, result []prey) []prey
+	rand    *rand.Rand // nil to share the game's
+	mode    AIMode
+	prey    prey
+	targetY float64
+	decided Tick
+	seen    []prey // room for targets
+}
+
+// BuzzardAI hunts the players with the tactics for its class on the current wave
+func BuzzardAI(class EnemyClass) *AIController {
+	return &AIController{
+		tactics: func(gs *GameState) Tactics {
+			return gs.Wave.Tactics[class]
+		},
+		targets: func(gs *GameState, result []prey) []prey {
+			for _, p := range gs.Players {
+				result = append(result, p)
+			}
+			return result
+		},
+	}
+}
+
+// PlayerAI flies a player's mount, hunting the enemies like a hunter would. It rolls its own
+// dice, since what it does is input to the game just like a person's would be, and a replay
+// or the other end of a network game won't be running it.
+func PlayerAI(seed int64) *AIController {
+	return &AIController{
+		rand: rand.New(rand.NewSource(seed)),
+		tactics: func(gs *GameState) Tactics {
+			return classTactics[HUNTER]
+		},
+		targets: func(gs *GameState, result []prey) []prey {
+			for _, b := range gs.Buzzards {
+				result = append(result, b)
+			}
+			return result
+		},
+	}
+}
+
+func (ai *AIController) Intent(gs *GameState, m *MountSprite) Intent {
+	if m == nil {
+		// join the game straight away
+		return Intent{Flap: true}
+	}
+	t := ai.tactics(gs)
+	ai.think(gs, m, t)
+
+	var in Intent
+	switch ai.mode {
+	case HUNTING:
+		target := ai.prey.mountSprite()
+		ai.targetY = target.Y - t.Climb
+		in.Right = gs.dx(m.X, target.X) > 0
+		in.Left = !in.Right
+	case EVADING:
+		in.Left = gs.dx(m.X, ai.prey.mountSprite().X) > 0
+		in.Right = !in.Left
+	}
+	in.Flap = ai.targetY < m.Y && !gs.Clock.Now().Before(m.lastFlap.Add(t.FlapTicks))
+	return in
+}
+
+// think picks what to do next, every so often depending on how quick the rider is
+func (ai *AIController) think(gs *GameState, m *MountSprite, t Tactics) {
+	now := gs.Clock.Now()
+	if ai.mode != PATROLLING && !ai.prey.catchable() {
+		ai.mode = PATROLLING
+	}
+	if now.Sub(ai.decided) < t.Reaction && ai.decided != 0 {
+		return
+	}
+	ai.decided = now
+
+	var nearest prey
+	closest := t.Range
+	ai.seen = ai.targets(gs, ai.seen[:0])
+	for _, p := range ai.seen {
+		if dist := gs.distance(m.Sprite, p.mountSprite().Sprite); p.catchable() && dist < closest {
+			closest = dist
+			nearest = p
+		}
+	}
+	r := ai.rand
+	if r == nil {
+		r = gs.Rand
+	}
+	switch {
+	case nearest != nil && nearest.mountSprite().Y < m.Y && r.Float64() < t.Caution:
+		ai.mode = EVADING
+		ai.prey = nearest
+		ai.targetY = gs.laneAwayFrom(nearest.mountSprite().Y)
+	case nearest != nil && r.Float64() < t.Aggression:
+		ai.mode = HUNTING
+		ai.prey = nearest
+	case ai.mode != PATROLLING || ai.targetY == 0 || math.Abs(m.Y-ai.targetY) < app.AILaneReached:
+		// head for another lane once it's reached the one it was patrolling
+		ai.mode = PATROLLING
+		ai.prey = nil
+		ai.targetY = float64(gs.Lanes[r.Intn(len(gs.Lanes))])
+	}
+}
+
+// laneAwayFrom is the lane furthest from the given height
+func (gs *GameState) laneAwayFrom(y float64) float64 {
+	result := float64(gs.Lanes[0])
+	for _, lane := range gs.Lanes {
+		if math.Abs(float64(lane)-y) > math.Abs(result-y) {
+			result = float64(lane)
+		}
+	}
+	return result
+}
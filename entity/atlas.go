@@ -0,0 +1,142 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"image"
+	"image/draw"
+)
+
+// atlasColumns is how many pictures go across each row of the atlas
+const atlasColumns = 32
+
+// mountKey picks out one picture of a mount and its rider
+type mountKey struct {
+	body       *image.RGBA // the mount's frame on the sheet
+	rider      *image.RGBA // nil for a mount with nobody on it
+	riderY     int
+	bodyColor  int // which of the spawn colors the mount is flashing, or -1 for its own
+	riderColor int // likewise for the rider
+	left       bool
+}
+
+// Atlas is every picture a mount can show, facing each way and flashing each of the spawn
+// colors, composed once when the sheet loads so picking one on a tick is just a lookup.
+// They're all cut from the one image, each with a mount's height of empty space above it,
+// so a mount rising out of a spawn pad is a window onto its picture too.
+type Atlas struct {
+	Image  *image.RGBA
+	mounts map[mountKey]*image.RGBA
+	rising map[*image.RGBA][]*image.RGBA // windows onto a picture, by how much of it is showing
+	held   map[*image.RGBA]bool
+}
+
+// buildAtlas composes the pictures of every mount and rider that go together
+func (s *Sheet) buildAtlas() *Atlas {
+	var keys []mountKey
+	add := func(frames []*image.RGBA, rider *image.RGBA, riderY func(frame int) int) {
+		for i, body := range frames {
+			for _, left := range []bool{false, true} {
+				keys = append(keys, mountKey{body, nil, 0, -1, -1, left})
+				keys = append(keys, mountKey{body, rider, riderY(i), -1, -1, left})
+				// spawning mounts flash, and their riders with them
+				for bodyColor := range app.SpawnColors {
+					keys = append(keys, mountKey{body, nil, 0, bodyColor, -1, left})
+					for riderColor := range app.SpawnColors {
+						keys = append(keys, mountKey{body, rider, riderY(i), bodyColor, riderColor, left})
+					}
+				}
+			}
+		}
+	}
+	noOffset := func(int) int { return 0 }
+	add(s.Ostrich, s.P1Rider, playerRiderY)
+	add(s.Stork, s.P2Rider, playerRiderY)
+	for _, rider := range s.Riders {
+		add(s.Buzzard, rider, noOffset)
+	}
+
+	cellW, cellH := 0, 0
+	for _, k := range keys {
+		cellW = max(cellW, k.body.Bounds().Dx())
+		cellH = max(cellH, 2*k.body.Bounds().Dy())
+	}
+	rows := (len(keys) + atlasColumns - 1) / atlasColumns
+	a := &Atlas{
+		Image:  image.NewRGBA(image.Rect(0, 0, atlasColumns*cellW, rows*cellH)),
+		mounts: make(map[mountKey]*image.RGBA, len(keys)),
+		rising: make(map[*image.RGBA][]*image.RGBA),
+		held:   make(map[*image.RGBA]bool),
+	}
+	for i, k := range keys {
+		composite := composeMount(k)
+		size := composite.Bounds().Size()
+		// the picture sits at the bottom of its cell, below its own height of nothing
+		at := image.Pt(i%atlasColumns*cellW, i/atlasColumns*cellH+cellH-size.Y)
+		r := image.Rectangle{Min: at, Max: at.Add(size)}
+		draw.Draw(a.Image, r, composite, image.Point{}, draw.Src)
+		img := a.cut(r)
+		a.mounts[k] = img
+		if k.body == s.Buzzard[spawnFrame] || k.body == s.Ostrich[spawnFrame] || k.body == s.Stork[spawnFrame] {
+			windows := make([]*image.RGBA, size.Y+1)
+			for shown := range windows {
+				windows[shown] = a.cut(r.Add(image.Pt(0, shown-size.Y)))
+			}
+			a.rising[img] = windows
+		}
+	}
+	return a
+}
+
+// cut is the part of the atlas image in r
+func (a *Atlas) cut(r image.Rectangle) *image.RGBA {
+	img := a.Image.SubImage(r).(*image.RGBA)
+	a.held[img] = true
+	return img
+}
+
+// Holds is whether img was cut from the atlas image
+func (a *Atlas) Holds(img *image.RGBA) bool {
+	return a.held[img]
+}
+
+// mount is the picture for k, only composed on the spot for a mount the atlas doesn't have
+func (a *Atlas) mount(k mountKey) *image.RGBA {
+	if img, ok := a.mounts[k]; ok {
+		return img
+	}
+	return composeMount(k)
+}
+
+// risingFrom is m with only its top shown rows showing, at the bottom, like a mount rising
+// out of a spawn pad
+func (a *Atlas) risingFrom(m *image.RGBA, shown int) *image.RGBA {
+	if windows, ok := a.rising[m]; ok && shown >= 0 && shown < len(windows) {
+		return windows[shown]
+	}
+	img := image.NewRGBA(image.Rectangle{Max: m.Bounds().Size()})
+	dst := img.Bounds().Add(image.Pt(0, m.Bounds().Dy()-shown))
+	draw.Draw(img, dst, m, m.Bounds().Min, draw.Over)
+	return img
+}
+
+// composeMount draws the mount with its rider on top, flashing and flipped as k says
+func composeMount(k mountKey) *image.RGBA {
+	composite := image.NewRGBA(k.body.Bounds())
+
+	var body image.Image = k.body
+	if k.bodyColor >= 0 {
+		body = drawSolid(composite.Bounds(), app.SpawnColors[k.bodyColor], k.body)
+	}
+	if k.rider != nil {
+		var rider image.Image = k.rider
+		if k.riderColor >= 0 {
+			rider = drawSolid(k.rider.Bounds(), app.SpawnColors[k.riderColor], k.rider)
+		}
+		draw.Draw(composite, k.rider.Bounds().Add(image.Pt(4, k.riderY)), rider, image.Point{}, draw.Over)
+	}
+	draw.Draw(composite, composite.Bounds(), body, image.Point{}, draw.Over)
+	if k.left {
+		return flipX(composite)
+	}
+	return composite
+}
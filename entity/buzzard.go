@@ -2,132 +2,214 @@ package entity
 
 import (
 	"github.com/depsypher/gojoust/app"
-	"github.com/depsypher/gojoust/assets/audio"
-	"github.com/hajimehoshi/ebiten/v2"
-	"log"
-	"math/rand"
-	"time"
+	"image"
+	"math"
 )
 
+// EnemyClass is the kind of rider on a buzzard
+type EnemyClass int
+
+const (
+	BOUNDER     EnemyClass = iota
+	HUNTER      EnemyClass = iota
+	SHADOW_LORD EnemyClass = iota
+)
+
+// promote is the class a rider comes back as after hatching from an egg
+func (c EnemyClass) promote() EnemyClass {
+	if c < SHADOW_LORD {
+		return c + 1
+	}
+	return c
+}
+
 type Buzzard struct {
 	*MountSprite
-	bounder     *ebiten.Image
-	lastAnimate time.Time
+	Class       EnemyClass
+	rider       *image.RGBA
+	lastAnimate Tick
+	Controller  Controller
 	state       PlayerState
+	egg         *Egg
 }
 
-func MakeBuzzard(ss *Sheet) *Buzzard {
+func MakeBuzzard(ss *Sheet, class EnemyClass) *Buzzard {
 	return &Buzzard{
 		MountSprite: MakeMountSprite(ss.Buzzard),
-		bounder:     ss.Bounder,
-		lastAnimate: time.Time{},
+		Class:       class,
+		rider:       ss.Riders[class],
+		Controller:  BuzzardAI(class),
 	}
 }
 
 func (b *Buzzard) spawning(gs *GameState) {
-	if time.Now().Before(b.lastAnimate.Add(time.Millisecond * time.Duration(30))) {
+	if gs.Clock.Now().Before(b.lastAnimate.Add(app.SpawnAnimTicks)) {
 		return
 	}
 	if b.spawn <= 20 {
 		// emerging
-		b.buildSpawn(b, b.spawn)
+		b.buildSpawn(gs, b, b.spawn)
 		b.spawn += 1
 		if b.spawn == 20 {
-			if err := gs.Sounds[audio.SpawnSound].Play(gs.SoundOn); err != nil {
-				log.Fatal("Error playing sound", err)
-			}
+			gs.PlaySound(app.SpawnSound)
 		}
 	} else {
 		b.state = MOUNTED
-		b.image = b.buildMount()
+		b.setFrame(gs.Sheet, b.buildMount(gs))
 		b.spawn = 0
 		b.Vy = 1
+		speed := gs.Wave.Tactics[b.Class].Speed
 		if b.FacingRight {
-			b.xSpeed = 1
+			b.xSpeed = speed
 		} else {
-			b.xSpeed = -1
+			b.xSpeed = -speed
 		}
 	}
-	b.lastAnimate = time.Now()
+	b.lastAnimate = gs.Clock.Now()
 }
 
-func (b *Buzzard) buildMount() *ebiten.Image {
-	frame := b.Images[b.Frame]
-	composite := ebiten.NewImage(frame.Bounds().Dx(), frame.Bounds().Dy())
-
-	body := ebiten.NewImageFromImage(frame)
+func (b *Buzzard) buildMount(gs *GameState) *image.RGBA {
+	k := mountKey{body: b.Images[b.Frame], bodyColor: -1, riderColor: -1, left: !b.FacingRight}
 	if b.state == SPAWNING {
-		col := app.SpawnColors[rand.Intn(3)]
-		body = b.drawSolid(composite.Bounds(), col, body)
+		k.bodyColor = gs.cosmetic.Intn(len(app.SpawnColors))
 	}
-	op := ebiten.DrawImageOptions{}
-
-	// draw rider
-	if b.state != UNMOUNTED {
-		y := 0
-		op.GeoM.Translate(float64(4), float64(y))
-		rider := ebiten.NewImageFromImage(b.bounder)
+	if b.state != UNMOUNTED && b.state != REMOUNTING {
+		k.rider = b.rider
 		if b.state == SPAWNING {
-			col := app.SpawnColors[rand.Intn(3)]
-			rider = b.drawSolid(rider.Bounds(), col, rider)
+			k.riderColor = gs.cosmetic.Intn(len(app.SpawnColors))
 		}
-		composite.DrawImage(rider, &op)
-		op.GeoM.Reset()
 	}
-	composite.DrawImage(body, &op)
-	if !b.FacingRight {
-		return b.flipX(composite, op)
-	}
-	return composite
+	return gs.Sheet.Atlas.mount(k)
 }
 
 func (b *Buzzard) mounted(gs *GameState) {
-	b.doFlap()
-	b.image = b.buildMount()
+	in := b.Controller.Intent(gs, b.MountSprite)
+	if in.Left != in.Right {
+		b.face(gs, in.Right)
+	}
+	b.flapWings(gs, in.Flap)
+	b.setFrame(gs.Sheet, b.buildMount(gs))
 
 	b.velocity()
-	b.Wrap()
+	if b.Wrap(gs) {
+		// turn back from the edge of an arena that doesn't wrap
+		b.FacingRight = b.X < gs.Width()/2
+		b.xSpeed = app.Abs(b.xSpeed)
+		if !b.FacingRight {
+			b.xSpeed = -b.xSpeed
+		}
+	}
+	if b.walking && !b.onCliff(gs) {
+		// the ledge burnt away from under it
+		b.walking = false
+	}
+	b.cliffCollision(gs)
+	if gs.inLava(b.Sprite) {
+		b.burn(gs)
+		return
+	}
+
+	b.Collisions(gs, gs.BuzzardsAsSprites(), func(c *Sprite) {
+		b.bounce(gs, c)
+	})
+}
+
+// face turns the buzzard to fly the given way at its usual speed
+func (b *Buzzard) face(gs *GameState, right bool) {
+	speed := app.Abs(b.xSpeed)
+	if speed == 0 {
+		speed = gs.Wave.Tactics[b.Class].Speed
+	}
+	b.FacingRight = right
+	b.xSpeed = speed
+	if !right {
+		b.xSpeed = -speed
+	}
+}
 
-	b.Collisions(gs.CliffAsSprites(), func(c *Sprite) {
-		if b.Y < c.centerY() && xBetween(b.X, c.rect(), 3) {
+func (b *Buzzard) cliffCollision(gs *GameState) {
+	b.Collisions(gs, gs.CliffAsSprites(), func(c *Sprite) {
+		if b.Y < c.centerY() && xBetween(b.X, gs.nearRect(b.Sprite, c), 3) {
 			// buzzard is above
 			b.Vy = 0.5
 			b.Y = c.Y - float64(b.Height/2)
 			b.walking = true
-		} else if b.Y-b.Vy > c.Y && xBetween(b.X, c.rect(), 0) {
+		} else if b.Y-b.Vy > c.Y && xBetween(b.X, gs.nearRect(b.Sprite, c), 0) {
 			// buzzard is below
 			b.Y += 3
 			b.Vy = 0.5
-		} else if b.centerX() < c.centerX() {
+		} else if gs.dx(b.centerX(), c.centerX()) > 0 {
 			// buzzard is to left
 			b.X -= 6
 			b.xSpeed = -b.xSpeed
 			b.FacingRight = false
-		} else if b.centerX() > c.centerX() {
+		} else if gs.dx(b.centerX(), c.centerX()) < 0 {
 			// buzzard is to right
 			b.X += 6
 			b.xSpeed = -b.xSpeed
 			b.FacingRight = true
 		}
 	})
+}
 
-	b.Collisions(gs.BuzzardsAsSprites(), func(c *Sprite) {
-		b.bounce(gs, c)
-	})
+// remounting flies toward a hatched rider and picks him up, unless the player collects
+// him first
+func (b *Buzzard) remounting(gs *GameState) {
+	e := b.egg
+	if !e.Alive {
+		b.egg = nil
+		b.state = UNMOUNTED
+		return
+	}
+
+	now := gs.Clock.Now()
+	// where the buzzard stands once the rider's on, with its feet where the egg was
+	y := e.Y + float64(e.Height-b.Height)/2
+	b.FacingRight = gs.dx(b.X, e.X) > 0
+	if b.FacingRight {
+		b.xSpeed = 2
+	} else {
+		b.xSpeed = -2
+	}
+	if b.Y > y && !now.Before(b.lastFlap.Add(app.FlapTicks)) {
+		b.Frame = 5
+		b.walking = false
+		b.Vy = -0.3
+		b.lastFlap = now
+	} else {
+		b.Frame = 6
+	}
+
+	b.velocity()
+	b.Wrap(gs)
+	b.cliffCollision(gs)
+	if math.Abs(gs.dx(b.X, e.X)) < 3 && math.Abs(b.Y-y) < 3 {
+		if !now.Before(e.settled.Add(app.EggPromoteTicks)) {
+			// the egg was left so long the rider comes back tougher
+			b.Class = e.class.promote()
+			b.rider = gs.Sheet.Riders[b.Class]
+			b.Controller = BuzzardAI(b.Class)
+		}
+		e.Alive = false
+		b.egg = nil
+		b.state = MOUNTED
+		b.SetPos(e.X, y)
+	}
+	b.setFrame(gs.Sheet, b.buildMount(gs))
 }
 
 func (b *Buzzard) unmounted(gs *GameState) {
-	if b.X < app.ScreenWidth/2 {
+	if b.X < gs.Width()/2 {
 		b.FacingRight = false
 		b.xSpeed = -3
 	} else {
 		b.FacingRight = true
 		b.xSpeed = 3
 	}
-	b.doFlap()
-	b.image = b.buildMount()
+	b.doFlap(gs, app.FlapTicks)
+	b.setFrame(gs.Sheet, b.buildMount(gs))
 	b.velocity()
-	if b.X < -float64(b.Width) || b.X > app.ScreenWidth+float64(b.Width/2) {
+	if b.X < -float64(b.Width) || b.X > gs.Width()+float64(b.Width/2) {
 		for i, buzz := range gs.Buzzards {
 			if buzz == b {
 				b.state = DEAD
@@ -140,21 +222,21 @@ func (b *Buzzard) unmounted(gs *GameState) {
 
 func (b *Buzzard) bounce(gs *GameState, collider *Sprite) bool {
 	above := false
-	if b.Y < collider.centerY() && xBetween(b.X, collider.rect(), 3) {
+	if b.Y < collider.centerY() && xBetween(b.X, gs.nearRect(b.Sprite, collider), 3) {
 		// buzzard is above
 		b.Vy = 0.5
 		b.Y = collider.Y - float64(b.Height/2)
 		b.walking = true
 		above = true
-	} else if b.Y-b.Vy > collider.Y && xBetween(b.X, collider.rect(), 0) {
+	} else if b.Y-b.Vy > collider.Y && xBetween(b.X, gs.nearRect(b.Sprite, collider), 0) {
 		// buzzard is below
 		b.Y += 3
 		b.Vy = 0.5
-	} else if b.centerX() < collider.centerX() {
+	} else if gs.dx(b.centerX(), collider.centerX()) > 0 {
 		// buzzard is to left
 		b.X -= 5
 		b.xSpeed = -2
-	} else if b.centerX() > collider.centerX() {
+	} else if gs.dx(b.centerX(), collider.centerX()) < 0 {
 		// buzzard is to right
 		b.X += 5
 		b.xSpeed = 2
@@ -170,11 +252,6 @@ func remove(s []*Buzzard, i int) []*Buzzard {
 func (b *Buzzard) dead(gs *GameState) {
 }
 
-func (b *Buzzard) Draw(screen *ebiten.Image) {
-	s := b.Sprite
-	s.DrawSprite(screen)
-}
-
 func (b *Buzzard) Update(gs *GameState) {
 	switch b.state {
 	case SPAWNING:
@@ -183,6 +260,8 @@ func (b *Buzzard) Update(gs *GameState) {
 		b.mounted(gs)
 	case UNMOUNTED:
 		b.unmounted(gs)
+	case REMOUNTING:
+		b.remounting(gs)
 	case DEAD:
 		b.dead(gs)
 	}
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"image"
+	"math"
+)
+
+// Width is how wide the world is: a single screen that wraps around, or the level laid out
+// across several screens for the camera to scroll along
+func (gs *GameState) Width() float64 {
+	if gs.Scrolling {
+		return app.ScrollScreens * app.ScreenWidth
+	}
+	return app.ScreenWidth
+}
+
+// Wraps is whether going off one side of the world brings you back on the other
+func (gs *GameState) Wraps() bool {
+	return gs.Level.Wrap && !gs.Scrolling
+}
+
+// dx is how far it is across from x1 to x2, going around the world if that's shorter
+func (gs *GameState) dx(x1, x2 float64) float64 {
+	dx := x2 - x1
+	if w := gs.Width(); gs.Wraps() && math.Abs(dx) > w/2 {
+		dx -= math.Copysign(w, dx)
+	}
+	return dx
+}
+
+// distance is how far apart two sprites are, going around the world if that's shorter
+func (gs *GameState) distance(a, b *Sprite) float64 {
+	return math.Hypot(gs.dx(a.X, b.X), b.Y-a.Y)
+}
+
+// nearRect is the bounds of the copy of c nearest to s, which is across the seam when the
+// world wraps and that's closer
+func (gs *GameState) nearRect(s, c *Sprite) image.Rectangle {
+	shift := gs.dx(s.centerX(), c.centerX()) - (c.centerX() - s.centerX())
+	return c.rect().Add(image.Pt(int(shift), 0))
+}
+
+// edge is where something flying in from the side of the world starts, just out of sight.
+// A world that wraps has no out of sight, so it starts just inside the edge instead.
+func (gs *GameState) edge(facingRight bool, width int) float64 {
+	w := float64(width / 2)
+	if gs.Wraps() {
+		w = -w
+	}
+	if facingRight {
+		return -w
+	}
+	return gs.Width() + w
+}
+
+// inView is whether x is on the part of the world the camera is showing
+func (gs *GameState) inView(x float64) bool {
+	return x >= gs.Camera && x < gs.Camera+app.ScreenWidth
+}
+
+// follow centers the camera on the first player still in the game, stopping at the ends
+// of the world
+func (gs *GameState) follow() {
+	if !gs.Scrolling {
+		gs.Camera = 0
+		return
+	}
+	for _, p := range gs.Players {
+		if p.Alive {
+			gs.Camera = math.Max(0, math.Min(p.X-app.ScreenWidth/2, gs.Width()-app.ScreenWidth))
+			return
+		}
+	}
+}
+
+// toggleScroll switches between the wrap around and scrolling arenas when the scroll button
+// is pressed. It goes through Keys so replays see it too.
+func (gs *GameState) toggleScroll() {
+	held := gs.Keys[app.ScrollButton]
+	if held && !gs.scrollHeld {
+		gs.setScrolling(!gs.Scrolling)
+	}
+	gs.scrollHeld = held
+}
+
+// setScrolling rebuilds the arena for the other mode in the middle of a wave. Ledges keep
+// whatever state they were in, and everything in the arena moves to the same spot of the
+// level in the middle screen of the scrolling world, or back from wherever it was when
+// returning to the single wrap around screen.
+func (gs *GameState) setScrolling(on bool) {
+	if on == gs.Scrolling {
+		return
+	}
+	gs.Scrolling = on
+	screens := 1
+	if on {
+		screens = app.ScrollScreens
+	}
+	old := gs.Cliffs
+	gs.Cliffs = gs.Level.makeCliffs(gs.Sheet, screens)
+	for i, c := range gs.Cliffs {
+		c.copyState(old[i%len(gs.Level.Cliffs)])
+	}
+
+	move := func(s *Sprite) {
+		if on {
+			s.X += app.ScrollScreens / 2 * app.ScreenWidth
+		} else {
+			s.X = math.Mod(s.X, app.ScreenWidth)
+		}
+	}
+	for _, p := range gs.Players {
+		move(p.Sprite)
+	}
+	for _, b := range gs.Buzzards {
+		move(b.Sprite)
+	}
+	for _, e := range gs.Eggs {
+		move(e.Sprite)
+	}
+	for _, p := range gs.Pteros {
+		move(p.Sprite)
+	}
+	move(gs.Troll.Sprite)
+	gs.follow()
+}
@@ -1,37 +1,269 @@
 package entity
 
 import (
-	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/depsypher/gojoust/app"
+	"image"
+	"image/draw"
+	"slices"
 )
 
+// the bridges run off the edges of the screen so there's no seam to trip over at the wrap
+const bridgeOverhang = 12
+
+// a ledge dissolves and reforms a few pixels at a time over this many steps
+const dissolveSteps = 16
+
+type CliffState int
+
+const (
+	STANDING   CliffState = iota
+	DISSOLVING CliffState = iota
+	GONE       CliffState = iota
+	RESTORING  CliffState = iota
+)
+
+// vanishing is the wave in each cycle of the level's waves that a ledge burns away on, and
+// the wave it comes back on
+type vanishing struct {
+	burn    int
+	restore int
+}
+
 type Cliff struct {
 	*Sprite
+	bridges bool   // the bottom cliff has a bridge over the lava at each end
+	reach   [2]int // how far the bridges reach in from each end
+	burnt   int    // columns burnt off the end of each bridge
+	spawn   *SpawnPad
+	vanish  vanishing
+	state   CliffState
+	changed Tick
+	step    int
+	frames  []*image.RGBA // the ledge dissolving, by how many steps have gone
+	masks   []*Mask       // of each of the frames
 }
 
-func MakeCliff(image *ebiten.Image, x float64, y float64) *Cliff {
-	// not sure why I can't use image directly, but the alpha channel is reversed?!
-	img := ebiten.NewImage(image.Bounds().Dx(), image.Bounds().Dy())
-	op := ebiten.DrawImageOptions{}
-	img.DrawImage(image, &op)
-
+func MakeCliff(img *image.RGBA, x float64, y float64) *Cliff {
 	result := &Cliff{
-		Sprite: MakeSprite([]*ebiten.Image{img}, x, y),
+		Sprite: MakeSprite([]*image.RGBA{img}, x, y),
+	}
+	for step := 0; step <= dissolveSteps; step++ {
+		frame := dissolve(img, step)
+		result.frames = append(result.frames, frame)
+		result.masks = append(result.masks, MaskOf(frame))
 	}
-	result.image = img
+	result.showStep()
 	result.center = false
 	return result
 }
 
-func MakeBottomCliff(image *ebiten.Image) *Cliff {
-	img := ebiten.NewImage(300, 30)
-	op := ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(70), float64(0))
-	img.DrawImage(image, &op)
+// MakeBottomCliff is the ground along the bottom of the arena, with a bridge either side
+// spanning the lava pit
+func MakeBottomCliff(cliff *image.RGBA, bridge *image.RGBA, x float64, y float64) *Cliff {
+	img := image.NewRGBA(image.Rect(0, 0, app.ScreenWidth+2*bridgeOverhang, cliff.Bounds().Dy()))
+	left := int(x) + bridgeOverhang
+	right := img.Bounds().Dx() - left - cliff.Bounds().Dx()
+	for x := 0; x < img.Bounds().Dx(); x += bridge.Bounds().Dx() {
+		// tuck each bridge a little way under the tapered ends of the cliff
+		if x < left+8 || x+bridge.Bounds().Dx() > img.Bounds().Dx()-right-8 {
+			draw.Draw(img, bridge.Bounds().Add(image.Pt(x, 0)), bridge, image.Point{}, draw.Src)
+		}
+	}
+	draw.Draw(img, cliff.Bounds().Add(image.Pt(left, 0)), cliff, image.Point{}, draw.Over)
 
 	result := &Cliff{
-		Sprite: MakeSprite([]*ebiten.Image{img}, -20, 178),
+		Sprite:  MakeSprite([]*image.RGBA{img}, -bridgeOverhang, y),
+		bridges: true,
+		reach:   [2]int{left, right},
 	}
-	result.image = img
+	result.setImage(img)
 	result.center = false
 	return result
 }
+
+// standsOn is whether the ledge is part of the arena on the given wave
+func (c *Cliff) standsOn(wave int, cycle int) bool {
+	if c.vanish.burn == 0 {
+		return true
+	}
+	w := (wave-1)%cycle + 1
+	return w < c.vanish.burn || w >= c.vanish.restore
+}
+
+// Solid is true unless the ledge is burning away or gone
+func (c *Cliff) Solid() bool {
+	return c.state == STANDING || c.state == RESTORING
+}
+
+// layoutCliffs starts ledges burning away or coming back for the new wave
+func (gs *GameState) layoutCliffs(wave int) {
+	now := gs.Clock.Now()
+	for _, c := range gs.Cliffs {
+		stands := c.standsOn(wave, gs.Level.Cycle)
+		if stands && !c.Solid() {
+			c.state = RESTORING
+			c.changed = now
+		} else if !stands && c.Solid() {
+			c.state = DISSOLVING
+			c.changed = now
+		}
+	}
+}
+
+// layoutLanes picks out the lanes to fly along this wave. Each lane is over the ledges
+// between it and the next lane down, and once they've all burnt away there's nothing
+// there to fly over. If that's every lane they're all kept.
+func (gs *GameState) layoutLanes() {
+	lanes := slices.Clone(gs.Level.Lanes)
+	slices.Sort(lanes)
+	gs.Lanes = gs.Lanes[:0]
+	for i, lane := range lanes {
+		below := gs.Level.LavaY
+		if i+1 < len(lanes) {
+			below = float64(lanes[i+1])
+		}
+		for _, c := range gs.Cliffs {
+			if c.Solid() && c.Y > float64(lane) && c.Y <= below {
+				gs.Lanes = append(gs.Lanes, lane)
+				break
+			}
+		}
+	}
+	if len(gs.Lanes) == 0 {
+		gs.Lanes = append(gs.Lanes, lanes...)
+	}
+}
+
+// updateCliffs moves along any ledges that are dissolving or reforming
+func (gs *GameState) updateCliffs() {
+	for _, c := range gs.Cliffs {
+		if c.state != DISSOLVING && c.state != RESTORING {
+			continue
+		}
+		step := min(gs.Clock.Now().Sub(c.changed)*dissolveSteps/app.DissolveTicks, dissolveSteps)
+		if step == c.step {
+			continue
+		}
+		c.step = step
+		if step == dissolveSteps {
+			if c.state == DISSOLVING {
+				c.state = GONE
+			} else {
+				c.state = STANDING
+			}
+			c.step = 0
+		}
+		c.showStep()
+	}
+}
+
+// showStep shows the ledge as far as it's got dissolving or reforming. It comes back in the
+// reverse of the order it went.
+func (c *Cliff) showStep() {
+	if c.frames == nil {
+		return
+	}
+	frame := c.step
+	switch c.state {
+	case GONE:
+		frame = dissolveSteps
+	case RESTORING:
+		frame = dissolveSteps - c.step
+	}
+	c.image = c.frames[frame]
+	c.mask = c.masks[frame]
+}
+
+// dissolve is the ledge with the pixels that have burnt away by the given step removed.
+// Pixels go in a scattered order.
+func dissolve(full *image.RGBA, step int) *image.RGBA {
+	b := full.Bounds()
+	img := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			h := uint32(x)*0x9e3779b1 ^ uint32(y)*0x85ebca77
+			h ^= h >> 15
+			h *= 0x2c1b3c6d
+			if int(h>>16)%dissolveSteps >= step {
+				img.SetRGBA(x, y, full.RGBAAt(x, y))
+			}
+		}
+	}
+	return img
+}
+
+// onCliff is whether the sprite is resting on a ledge. Anything landing is set down with the
+// bottom of its frame on the ledge, so that's where to look even when the frame is clear at
+// the bottom.
+func (s *Sprite) onCliff(gs *GameState) bool {
+	drop := 1.0
+	if s.mask != nil {
+		drop += float64(s.mask.Height - s.mask.base())
+	}
+	s.Y += drop
+	defer func() { s.Y -= drop }()
+	for _, c := range gs.CliffAsSprites() {
+		if s.Collides(gs, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyState picks up where another copy of the same ledge had got to burning away
+func (c *Cliff) copyState(from *Cliff) {
+	c.bridges = from.bridges
+	c.burnt = from.burnt
+	c.state = from.state
+	c.changed = from.changed
+	c.step = from.step
+	if c.frames != nil {
+		c.showStep()
+	} else {
+		// the bottom cliff burns its own picture, so it needs its own copy of the burns
+		copy(c.image.Pix, from.image.Pix)
+		c.mask = MaskOf(c.image)
+	}
+}
+
+// spawnPoint picks one of the spawn pads on a ledge that's still there, or any pad at all
+// if none are. Players only appear where the camera can see them.
+func (gs *GameState) spawnPoint(players bool) (x, y int) {
+	gs.pads = gs.spawnPads(players, true, gs.pads[:0])
+	if len(gs.pads) == 0 {
+		gs.pads = gs.spawnPads(players, false, gs.pads)
+	}
+	return gs.pads[gs.Rand.Intn(len(gs.pads))].spawnPoint()
+}
+
+// playerSpawnPoint is where the numbered player first appears, each on their own pad
+func (gs *GameState) playerSpawnPoint(number int) (x, y int) {
+	gs.pads = gs.spawnPads(true, false, gs.pads[:0])
+	return gs.pads[number%len(gs.pads)].spawnPoint()
+}
+
+// spawnPads adds the ledges with a pad to spawn on to result, only those still standing
+// if asked
+func (gs *GameState) spawnPads(players bool, standing bool, result []*Cliff) []*Cliff {
+	for _, c := range gs.Cliffs {
+		if c.spawn == nil || (standing && !c.Solid()) {
+			continue
+		}
+		if players {
+			if x, _ := c.spawnPoint(); !c.spawn.Players || !gs.inView(float64(x)) {
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// spawnPoint is where a mount standing on the ledge's spawn pad is centered
+func (c *Cliff) spawnPoint() (x, y int) {
+	x = int(c.X) + c.spawn.X
+	if c.bridges {
+		x += c.reach[0]
+	}
+	return x, int(c.Y) - 10
+}
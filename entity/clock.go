@@ -0,0 +1,41 @@
+package entity
+
+// Tick is a point in simulation time, counted in fixed updates since the game started.
+// Tick zero is never simulated, so the zero value can be used to mean "not set".
+type Tick int64
+
+// Clock is the simulation clock. It only moves when the simulation is advanced, so every
+// timer in the game is measured in ticks rather than wall-clock time. A timer of n ticks is
+// up once n ticks have gone by, that is when !now.Before(start.Add(n)).
+type Clock struct {
+	now Tick
+}
+
+func (c *Clock) Now() Tick {
+	return c.now
+}
+
+// Advance moves the clock forward by exactly one tick
+func (c *Clock) Advance() {
+	c.now++
+}
+
+func (t Tick) Add(ticks int) Tick {
+	return t + Tick(ticks)
+}
+
+func (t Tick) Sub(u Tick) int {
+	return int(t - u)
+}
+
+func (t Tick) After(u Tick) bool {
+	return t > u
+}
+
+func (t Tick) Before(u Tick) bool {
+	return t < u
+}
+
+func (t Tick) IsZero() bool {
+	return t == 0
+}
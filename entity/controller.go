@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+)
+
+// Intent is what the rider of a mount wants to do on a tick
+type Intent struct {
+	Left  bool
+	Right bool
+	Flap  bool
+}
+
+// Any is true if any control is held, which is how a player waiting to materialize gets
+// going early
+func (in Intent) Any() bool {
+	return in.Left || in.Right || in.Flap
+}
+
+// Controller drives a mount, whether that's someone at a keyboard or gamepad, the other end
+// of a network game, a replay or the computer. It's asked once a tick for what the mount's
+// rider wants to do. For a player slot nobody has joined yet m is nil, and a flap joins.
+type Controller interface {
+	Intent(gs *GameState, m *MountSprite) Intent
+}
+
+// KeyController reads the intent from the controls held in gs.Keys, which is how the game
+// gets driven when nothing is plugged in for a player
+type KeyController app.Controls
+
+func (k KeyController) Intent(gs *GameState, m *MountSprite) Intent {
+	return Intent{
+		Left:  gs.Keys[k.Left],
+		Right: gs.Keys[k.Right],
+		Flap:  gs.Keys[k.Flap],
+	}
+}
+
+// pollControllers asks each player slot's controller for this tick's intent
+func (gs *GameState) pollControllers() {
+	for n, c := range gs.Controllers {
+		var m *MountSprite
+		if n < len(gs.Players) {
+			m = gs.Players[n].MountSprite
+		}
+		gs.Intents[n] = c.Intent(gs, m)
+	}
+}
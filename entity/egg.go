@@ -0,0 +1,176 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"image"
+	"math"
+)
+
+type EggState int
+
+const (
+	FALLING  EggState = iota
+	SETTLED  EggState = iota
+	HATCHING EggState = iota
+	HATCHED  EggState = iota
+)
+
+// Egg is what's left of an enemy rider after being unhorsed. It bounces to a stop and, if
+// nobody collects it, hatches into a rider who waits for a buzzard to come pick him up.
+type Egg struct {
+	*Sprite
+	class   EnemyClass
+	knight  *image.RGBA
+	state   EggState
+	settled Tick
+	mount   *Buzzard
+}
+
+func MakeEgg(ss *Sheet, class EnemyClass, x, y, vx, vy float64) *Egg {
+	e := &Egg{
+		Sprite: MakeSprite(ss.Egg, x, y),
+		class:  class,
+		knight: ss.Knights[class],
+	}
+	e.Vx = vx
+	e.Vy = vy
+	e.setFrame(ss, e.Images[0])
+	return e
+}
+
+func (e *Egg) Update(gs *GameState) {
+	switch e.state {
+	case FALLING:
+		e.falling(gs)
+	case SETTLED:
+		e.waiting(gs)
+	case HATCHING:
+		e.hatching(gs)
+	case HATCHED:
+		e.hatched(gs)
+	}
+}
+
+func (e *Egg) falling(gs *GameState) {
+	e.Fall()
+	e.X += e.Vx
+	if e.Wrap(gs) {
+		e.Vx = -e.Vx
+	}
+
+	landed := false
+	e.Collisions(gs, gs.CliffAsSprites(), func(c *Sprite) {
+		if e.Y < c.centerY() && xBetween(e.X, gs.nearRect(e.Sprite, c), 0) {
+			// egg is above
+			e.Y = c.Y - float64(e.Height)/2
+			landed = true
+		} else if e.Y-e.Vy > c.Y && xBetween(e.X, gs.nearRect(e.Sprite, c), 0) {
+			// egg is below
+			e.Y += 2
+			e.Vy = math.Abs(e.Vy) / 2
+		} else {
+			// egg hit the side
+			e.Vx = -e.Vx
+			if gs.dx(e.centerX(), c.centerX()) > 0 {
+				e.X -= 2
+			} else {
+				e.X += 2
+			}
+		}
+	})
+
+	if landed {
+		e.Vx *= 0.6
+		if e.Vy > 0.6 {
+			e.Vy = -e.Vy * 0.4
+		} else {
+			e.Vx = 0
+			e.Vy = 0
+			e.state = SETTLED
+			e.settled = gs.Clock.Now()
+		}
+	}
+
+	if gs.inLava(e.Sprite) {
+		e.Alive = false
+	}
+}
+
+func (e *Egg) waiting(gs *GameState) {
+	if !e.onCliff(gs) {
+		// the ledge burnt away from under it
+		e.state = FALLING
+		return
+	}
+	if !gs.Clock.Now().Before(e.settled.Add(app.EggHatchTicks)) {
+		e.state = HATCHING
+	}
+}
+
+func (e *Egg) hatching(gs *GameState) {
+	e.sink(gs)
+	elapsed := gs.Clock.Now().Sub(e.settled.Add(app.EggHatchTicks))
+	if elapsed < app.EggWobbleTicks {
+		// wobble back and forth
+		e.setFrame(gs.Sheet, e.Images[1+(elapsed/8)%2])
+	} else if elapsed < app.EggWobbleTicks+app.EggCrackTicks {
+		e.setFrame(gs.Sheet, e.Images[3])
+	} else {
+		e.state = HATCHED
+		gs.Wave.hatched = true
+		e.setFrame(gs.Sheet, e.knight)
+	}
+}
+
+// hatched sends a riderless buzzard in from the nearest edge to pick up the new rider
+func (e *Egg) hatched(gs *GameState) {
+	e.sink(gs)
+	if e.mount != nil || !e.Alive {
+		return
+	}
+	b := MakeBuzzard(gs.Sheet, e.class)
+	b.state = REMOUNTING
+	b.egg = e
+	b.FacingRight = e.X < gs.Width()/2
+	b.SetPos(gs.edge(b.FacingRight, b.Width), math.Max(float64(b.Height), e.Y-40))
+	b.setFrame(gs.Sheet, b.buildMount(gs))
+	e.mount = b
+	gs.Buzzards = append(gs.Buzzards, b)
+}
+
+// sink drops a hatching egg or a new rider whose ledge burnt away from under them until
+// they come to rest on something else, which over the pit is the lava
+func (e *Egg) sink(gs *GameState) {
+	if e.onCliff(gs) {
+		e.Vy = 0
+		return
+	}
+	e.Fall()
+	if gs.inLava(e.Sprite) {
+		e.Alive = false
+	}
+}
+
+// collect awards the player for picking up the egg or the rider that hatched from it.
+// Each egg in a row is worth more, and catching one before it lands earns a bonus.
+func (e *Egg) collect(gs *GameState, p *Player) {
+	value := app.EggPoints * (p.eggsInARow + 1)
+	if value > app.MaxEggPoints {
+		value = app.MaxEggPoints
+	}
+	if e.state == FALLING {
+		value += app.EggCatchPoints
+	}
+	p.award(gs, value)
+	p.eggsInARow++
+	e.Alive = false
+	gs.PlaySound(app.EggSound)
+}
+
+func eggCollision(gs *GameState, p *Player) {
+	for _, e := range gs.Eggs {
+		if e.Alive && p.Collides(gs, e.Sprite) {
+			e.collect(gs, p)
+		}
+	}
+}
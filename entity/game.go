@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// NewGameState sets up the arena and player for a new game on the given level. The
+// simulation is fully determined by the level, the seed, the mode and the intents and
+// buttons held on each tick.
+func NewGameState(ss *Sheet, level *Level, seed int64, mode Mode) *GameState {
+	gs := &GameState{
+		Keys:  make(map[app.Control]bool),
+		Sheet: ss,
+		Level: level,
+		Seed:  seed,
+		Mode:  mode,
+		Rand:  rand.New(rand.NewSource(seed)),
+	}
+	gs.cosmetic = rand.New(rand.NewSource(seed))
+	gs.Cliffs = level.makeCliffs(ss, 1)
+	for _, c := range app.PlayerControls {
+		gs.Controllers = append(gs.Controllers, KeyController(c))
+	}
+	gs.Intents = make([]Intent, len(gs.Controllers))
+
+	p := MakePlayer(ss, 0)
+	x, y := gs.playerSpawnPoint(0)
+	p.SetPos(float64(x), float64(y))
+	gs.Players = []*Player{p}
+
+	gs.Troll = MakeTroll(ss)
+	gs.startWave(1)
+	return gs
+}
+
+// Update advances the simulation by exactly one tick using what the controllers want and
+// the buttons currently held in Keys
+func (gs *GameState) Update() {
+	gs.Clock.Advance()
+	gs.pollControllers()
+	gs.toggleScroll()
+	gs.joinPlayers()
+	gs.updateWave()
+	gs.updateCliffs()
+	gs.burnBridges()
+
+	for _, p := range gs.Players {
+		p.Update(gs)
+	}
+	for _, b := range gs.Buzzards {
+		b.Update(gs)
+	}
+	for _, e := range gs.Eggs {
+		e.Update(gs)
+	}
+	for _, p := range gs.Pteros {
+		p.Update(gs)
+	}
+	gs.Troll.Update(gs)
+
+	gs.Buzzards = slices.DeleteFunc(gs.Buzzards, func(b *Buzzard) bool {
+		return !b.Alive
+	})
+	gs.Eggs = slices.DeleteFunc(gs.Eggs, func(e *Egg) bool {
+		return !e.Alive
+	})
+	gs.Pteros = slices.DeleteFunc(gs.Pteros, func(p *Pterodactyl) bool {
+		return !p.Alive
+	})
+	gs.follow()
+}
+
+// joinPlayers brings the next player into the game when they flap
+func (gs *GameState) joinPlayers() {
+	n := len(gs.Players)
+	if gs.GameOver || n >= len(gs.Controllers) || !gs.Intents[n].Flap {
+		return
+	}
+	p := MakePlayer(gs.Sheet, n)
+	x, y := gs.playerSpawnPoint(n)
+	p.spawnAt(gs, x, y)
+	gs.Players = append(gs.Players, p)
+}
+
+// nearestPlayer is the player still in the game closest to the sprite, going around the
+// world if that's shorter
+func (gs *GameState) nearestPlayer(s *Sprite) *Player {
+	result := gs.Players[0]
+	closest := math.MaxFloat64
+	for _, p := range gs.Players {
+		dist := gs.distance(s, p.Sprite)
+		if p.Alive && dist < closest {
+			closest = dist
+			result = p
+		}
+	}
+	return result
+}
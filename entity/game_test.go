@@ -0,0 +1,464 @@
+package entity
+
+import (
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"image"
+	"slices"
+	"testing"
+)
+
+func newGame(tb testing.TB, seed int64) *GameState {
+	tb.Helper()
+	ss, err := LoadSpriteSheet()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return NewGameState(ss, DefaultLevel(), seed, Competitive)
+}
+
+// flownByAI has the computer fly every player, joining the second straight away
+func flownByAI(gs *GameState, seed int64) *GameState {
+	for n := range gs.Controllers {
+		gs.Controllers[n] = PlayerAI(seed + int64(n))
+	}
+	return gs
+}
+
+// fingerprint sums up the state of the game, to tell whether two games went the same way
+func fingerprint(gs *GameState) string {
+	s := fmt.Sprintf("tick %d wave %d over %v\n", gs.Clock.Now(), gs.Wave.Number, gs.GameOver)
+	for _, p := range gs.Players {
+		s += fmt.Sprintf("player %d %v at %.3f,%.3f score %d lives %d\n", p.Number, p.state, p.X, p.Y, p.Score, p.Lives)
+	}
+	for _, b := range gs.Buzzards {
+		s += fmt.Sprintf("buzzard %v %v at %.3f,%.3f\n", b.Class, b.state, b.X, b.Y)
+	}
+	for _, e := range gs.Eggs {
+		s += fmt.Sprintf("egg %v at %.3f,%.3f\n", e.state, e.X, e.Y)
+	}
+	return s + fmt.Sprintf("next roll %d", gs.Rand.Int63())
+}
+
+func TestClockTimers(t *testing.T) {
+	var c Clock
+	c.Advance()
+	start := c.Now()
+	for i := 1; i < app.FlapTicks; i++ {
+		c.Advance()
+		if !c.Now().Before(start.Add(app.FlapTicks)) {
+			t.Fatalf("timer of %d ticks was up after %d", app.FlapTicks, i)
+		}
+	}
+	c.Advance()
+	if c.Now().Before(start.Add(app.FlapTicks)) {
+		t.Fatalf("timer of %d ticks wasn't up after %d", app.FlapTicks, app.FlapTicks)
+	}
+	if got := c.Now().Sub(start); got != app.FlapTicks {
+		t.Errorf("%d ticks went by, want %d", got, app.FlapTicks)
+	}
+}
+
+func TestUpdateIsDeterministic(t *testing.T) {
+	a := flownByAI(newGame(t, 7), 7)
+	b := flownByAI(newGame(t, 7), 7)
+	for i := 0; i < 60*app.TicksPerSecond; i++ {
+		a.Update()
+		b.Update()
+	}
+	if fa, fb := fingerprint(a), fingerprint(b); fa != fb {
+		t.Errorf("two games on the same seed went differently:\n%s\nand:\n%s", fa, fb)
+	}
+	if len(a.Players) != 2 {
+		t.Errorf("%d players joined, want 2", len(a.Players))
+	}
+}
+
+func TestJoustHigherLanceWins(t *testing.T) {
+	tests := []struct {
+		name       string
+		buzzardDy  float64
+		playerWins bool
+	}{
+		{"player above", 6, true},
+		{"player below", -6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := newGame(t, 1)
+			p := gs.Players[0]
+			p.state = MOUNTED
+			p.SetPos(150, 30)
+			p.setFrame(gs.Sheet, p.buildMount(gs))
+			b := MakeBuzzard(gs.Sheet, HUNTER)
+			b.state = MOUNTED
+			b.SetPos(150, 30+tt.buzzardDy)
+			b.setFrame(gs.Sheet, b.buildMount(gs))
+			gs.Buzzards = []*Buzzard{b}
+			gs.DrainSounds()
+
+			buzzardCollision(gs, p)
+			if tt.playerWins {
+				if b.state != UNMOUNTED || p.state != MOUNTED {
+					t.Errorf("buzzard %v and player %v, want the buzzard unhorsed", b.state, p.state)
+				}
+				if p.Score != app.HunterPoints {
+					t.Errorf("scored %d, want %d", p.Score, app.HunterPoints)
+				}
+				if len(gs.Eggs) != 1 || gs.Eggs[0].class != HUNTER {
+					t.Errorf("left %d eggs, want a hunter's", len(gs.Eggs))
+				}
+			} else if p.state != UNMOUNTED || b.state != MOUNTED {
+				t.Errorf("buzzard %v and player %v, want the player unhorsed", b.state, p.state)
+			}
+			if !hasSound(gs, app.HitSound) {
+				t.Error("no hit sound")
+			}
+		})
+	}
+}
+
+func hasSound(gs *GameState, sound app.Sound) bool {
+	return slices.ContainsFunc(gs.DrainSounds(), func(e SoundEvent) bool {
+		return e.Sound == sound && e.Action == PlaySound
+	})
+}
+
+// eggOn lays an egg on the named ledge, settled the given number of ticks ago
+func eggOn(t *testing.T, gs *GameState, ledge string, age int) *Egg {
+	t.Helper()
+	for i, lc := range gs.Level.Cliffs {
+		if lc.Name == ledge {
+			c := gs.Cliffs[i]
+			e := MakeEgg(gs.Sheet, BOUNDER, c.centerX(), 0, 0, 0)
+			e.Y = c.Y - float64(e.Height)/2
+			e.state = SETTLED
+			e.settled = gs.Clock.Now().Add(-age)
+			gs.Eggs = append(gs.Eggs, e)
+			return e
+		}
+	}
+	t.Fatalf("no ledge called %s", ledge)
+	return nil
+}
+
+func TestEggHatchesAndRemounts(t *testing.T) {
+	gs := newGame(t, 1)
+	for i := 0; i < app.TicksPerSecond; i++ {
+		gs.Update()
+	}
+	e := eggOn(t, gs, "top-right", 0)
+	for i := 1; i < app.EggHatchTicks+app.EggWobbleTicks+app.EggCrackTicks; i++ {
+		gs.Update()
+		if e.state == HATCHED {
+			t.Fatalf("hatched after %d ticks", i)
+		}
+	}
+	gs.Update()
+	if e.state != HATCHED {
+		t.Fatalf("egg %v after %d ticks, want hatched", e.state, app.EggHatchTicks+app.EggWobbleTicks+app.EggCrackTicks)
+	}
+	gs.Update()
+	if e.mount == nil || e.mount.state != REMOUNTING {
+		t.Fatal("no buzzard on the way to pick up the rider")
+	}
+	if !gs.Wave.hatched {
+		t.Error("the wave didn't see the egg hatch")
+	}
+
+	// picked up straight away, the rider comes back as it was
+	b := e.mount
+	b.SetPos(e.X, e.Y+float64(e.Height-b.Height)/2)
+	gs.Update()
+	if e.Alive || b.state != MOUNTED || b.Class != BOUNDER {
+		t.Errorf("egg alive %v, buzzard %v %v, want a bounder remounted", e.Alive, b.state, b.Class)
+	}
+}
+
+func TestLeftEggRemountsPromoted(t *testing.T) {
+	gs := newGame(t, 1)
+	for i := 0; i < app.EggPromoteTicks; i++ {
+		gs.Update()
+	}
+	e := eggOn(t, gs, "top-left", app.EggPromoteTicks)
+	for i := 0; i < 10*app.TicksPerSecond && e.Alive; i++ {
+		gs.Update()
+	}
+	b := e.mount
+	if e.Alive || b == nil || b.state != MOUNTED {
+		t.Fatalf("egg %v alive %v, the buzzard never picked up the rider", e.state, e.Alive)
+	}
+	if b.Class != HUNTER {
+		t.Errorf("a bounder left %d ticks came back as %v, want %v", app.EggPromoteTicks, b.Class, HUNTER)
+	}
+	if y := e.Y + float64(e.Height-b.Height)/2; b.X != e.X || b.Y != y {
+		t.Errorf("remounted at %v,%v, want where the rider was at %v,%v", b.X, b.Y, e.X, y)
+	}
+}
+
+func TestRemountsOnlyAlongside(t *testing.T) {
+	gs := newGame(t, 1)
+	e := eggOn(t, gs, "top-left", 0)
+	e.X = 1
+	e.state = HATCHED
+	e.setFrame(gs.Sheet, e.knight)
+	gs.Update()
+	b := e.mount
+	if b == nil {
+		t.Fatal("no buzzard sent for the rider")
+	}
+	y := e.Y + float64(e.Height-b.Height)/2
+	b.SetPos(e.X, y-40)
+	gs.Update()
+	if !e.Alive || b.state != REMOUNTING {
+		t.Fatal("the rider was picked up from 40 pixels below the buzzard")
+	}
+	b.SetPos(gs.Width()-1, y)
+	gs.Update()
+	if e.Alive || b.state != MOUNTED {
+		t.Errorf("egg alive %v, buzzard %v, want the rider picked up across the seam", e.Alive, b.state)
+	}
+}
+
+func TestExtraLife(t *testing.T) {
+	gs := newGame(t, 1)
+	p := gs.Players[0]
+	p.award(gs, app.ExtraLifePoints-100)
+	gs.DrainSounds()
+	if p.Lives != app.StartingLives {
+		t.Fatalf("%d lives before reaching %d points, want %d", p.Lives, app.ExtraLifePoints, app.StartingLives)
+	}
+	p.award(gs, app.BounderPoints)
+	if p.Lives != app.StartingLives+1 {
+		t.Errorf("%d lives after reaching %d points, want %d", p.Lives, app.ExtraLifePoints, app.StartingLives+1)
+	}
+	if !hasSound(gs, app.OneUpSound) {
+		t.Error("no sound for the extra life")
+	}
+}
+
+func TestLosingLives(t *testing.T) {
+	gs := newGame(t, 1)
+	p := gs.Players[0]
+	p.Lives = 1
+	p.state = DEAD
+	p.Update(gs)
+	if p.Lives != 0 || p.state != SPAWNING || gs.GameOver {
+		t.Fatalf("after dying with a life left: %d lives, state %v, game over %v", p.Lives, p.state, gs.GameOver)
+	}
+	if !gs.Wave.died[p] {
+		t.Error("the wave didn't see the player die")
+	}
+	p.state = DEAD
+	p.Update(gs)
+	if p.Alive || !gs.GameOver {
+		t.Errorf("after dying on the last life: alive %v, game over %v", p.Alive, gs.GameOver)
+	}
+}
+
+func TestCoopPlayersBounce(t *testing.T) {
+	for _, mode := range []Mode{Competitive, Coop} {
+		gs := newGame(t, 1)
+		gs.Mode = mode
+		players := []*Player{gs.Players[0], MakePlayer(gs.Sheet, 1)}
+		gs.Players = players
+		for i, p := range players {
+			p.state = MOUNTED
+			p.SetPos(150, 30+6*float64(i))
+			p.setImage(p.buildMount(gs))
+		}
+		playerCollision(gs, players[0])
+		unhorsed := players[1].state == UNMOUNTED
+		if unhorsed != (mode == Competitive) {
+			t.Errorf("mode %v: player two unhorsed %v", mode, unhorsed)
+		}
+	}
+}
+
+// clearWave gets rid of everything left in the wave, as if the players had
+func clearWave(gs *GameState) {
+	gs.Wave.spawned = len(gs.Wave.Enemies)
+	gs.Buzzards = nil
+	gs.Eggs = nil
+}
+
+func TestWaveEnds(t *testing.T) {
+	tests := []struct {
+		number int
+		kind   WaveKind
+		setup  func(gs *GameState)
+		bonus  int
+	}{
+		{1, NormalWave, nil, 0},
+		{2, SurvivalWave, nil, app.SurvivalPoints},
+		{2, SurvivalWave, func(gs *GameState) { gs.Wave.died[gs.Players[0]] = true }, 0},
+		{4, NormalWave, nil, 0}, // no gladiator wave on your own
+		{5, EggWave, nil, app.EggWavePoints},
+		{5, EggWave, func(gs *GameState) { gs.Wave.hatched = true }, 0},
+		{8, PterodactylWave, func(gs *GameState) { gs.Wave.slain[gs.Players[0]] = true }, app.PteroWavePoints},
+		{8, PterodactylWave, nil, 0},
+	}
+	for _, tt := range tests {
+		gs := newGame(t, 1)
+		gs.startWave(tt.number)
+		if gs.Wave.Kind != tt.kind {
+			t.Fatalf("wave %d is kind %v, want %v", tt.number, gs.Wave.Kind, tt.kind)
+		}
+		for gs.Wave.ShowBanner(gs.Clock.Now()) {
+			gs.Update()
+		}
+		if tt.setup != nil {
+			tt.setup(gs)
+		}
+		clearWave(gs)
+		before := gs.Players[0].Score
+		gs.Update()
+		if gs.Wave.Number != tt.number+1 {
+			t.Errorf("wave %d: on wave %d once cleared, want %d", tt.number, gs.Wave.Number, tt.number+1)
+		}
+		if got := gs.Players[0].Score - before; got != tt.bonus {
+			t.Errorf("wave %d %v: bonus of %d, want %d", tt.number, tt.kind, got, tt.bonus)
+		}
+	}
+}
+
+func TestWaveWaitsForEggs(t *testing.T) {
+	gs := newGame(t, 1)
+	for gs.Wave.ShowBanner(gs.Clock.Now()) {
+		gs.Update()
+	}
+	clearWave(gs)
+	gs.Eggs = []*Egg{MakeEgg(gs.Sheet, BOUNDER, 150, 20, 0, 0)}
+	gs.Update()
+	if gs.Wave.Number != 1 {
+		t.Errorf("moved on to wave %d with an egg left", gs.Wave.Number)
+	}
+}
+
+// burnBridges starts the wave the bridges burn on and waits for them to burn away
+func burnBridges(t *testing.T, gs *GameState) {
+	t.Helper()
+	gs.startWave(app.BridgeWave)
+	for i := 0; i < 30*app.TicksPerSecond && gs.BridgesStanding(); i++ {
+		gs.Update()
+	}
+	if gs.BridgesStanding() {
+		t.Fatal("the bridges never burnt away")
+	}
+}
+
+// columnClear is true when nothing is drawn in the column of the picture
+func columnClear(img *image.RGBA, x int) bool {
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		if img.RGBAAt(x, y).A != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBridgesBurn(t *testing.T) {
+	gs := newGame(t, 1)
+	bottom := gs.Cliffs[0]
+	if columnClear(bottom.Image(), 0) {
+		t.Fatal("no bridge at the edge of the screen to begin with")
+	}
+	drawn, version := bottom.Image(), bottom.Version()
+	burnBridges(t, gs)
+	if !columnClear(bottom.Image(), 0) || !columnClear(bottom.Image(), bottom.Width-1) {
+		t.Error("the bridges are still there at the edges of the screen")
+	}
+	if bottom.Image() == drawn && bottom.Version() == version {
+		t.Error("the bridges burnt away without the cliff's image changing, so they'd still be drawn")
+	}
+}
+
+func TestTrollGrabsLowFlyer(t *testing.T) {
+	gs := newGame(t, 1)
+	burnBridges(t, gs)
+	clearWave(gs)
+	b := MakeBuzzard(gs.Sheet, BOUNDER)
+	b.state = MOUNTED
+	b.SetPos(20, gs.Level.LavaY-20)
+	b.setImage(b.buildMount(gs))
+	gs.Buzzards = []*Buzzard{b}
+	gs.DrainSounds()
+	for i := 0; i < 5*app.TicksPerSecond && b.Alive; i++ {
+		gs.Update()
+	}
+	if b.Alive {
+		t.Fatalf("buzzard %v at %v,%v got away from the troll", b.state, b.X, b.Y)
+	}
+	if !hasSound(gs, app.LavaSound) {
+		t.Error("no sound of the buzzard hitting the lava")
+	}
+}
+
+func TestCollidesAcrossSeam(t *testing.T) {
+	gs := newGame(t, 1)
+	left := MakeEgg(gs.Sheet, BOUNDER, 1, 40, 0, 0)
+	right := MakeEgg(gs.Sheet, BOUNDER, gs.Width()-2, 40, 0, 0)
+	if !left.Collides(gs, right.Sprite) || !right.Collides(gs, left.Sprite) {
+		t.Error("eggs either side of the seam didn't collide")
+	}
+	right.Y += float64(right.Height)
+	if left.Collides(gs, right.Sprite) {
+		t.Error("eggs a whole egg apart collided")
+	}
+}
+
+func TestEggOnBurntBridgeSinks(t *testing.T) {
+	for _, state := range []EggState{SETTLED, HATCHING, HATCHED} {
+		gs := newGame(t, 1)
+		gs.startWave(app.BridgeWave)
+		e := eggOn(t, gs, "bottom", 0)
+		e.X = 4 // out on the bridge
+		e.state = state
+		e.mount = &Buzzard{} // nothing to pick up a hatched rider
+		for i := 0; i < 30*app.TicksPerSecond && e.Alive; i++ {
+			gs.Update()
+		}
+		if e.Alive {
+			t.Errorf("egg %v still at %v,%v after the bridge burnt", state, e.X, e.Y)
+		}
+	}
+}
+
+// playOn advances the game a tick, handing the players back their lives so it never ends
+func playOn(gs *GameState) {
+	for _, p := range gs.Players {
+		p.Lives = app.StartingLives
+	}
+	gs.Update()
+	gs.DrainSounds()
+}
+
+// underWay is a game flown by the computer that's had time to get going
+func underWay(tb testing.TB) *GameState {
+	gs := flownByAI(newGame(tb, 1), 1)
+	for i := 0; i < 10*app.TicksPerSecond; i++ {
+		playOn(gs)
+	}
+	return gs
+}
+
+func TestUpdateDoesNotAllocate(t *testing.T) {
+	gs := underWay(t)
+	// enemies and eggs are still made as they turn up, but that's far less than once a tick
+	if n := testing.AllocsPerRun(100000, func() { playOn(gs) }); n != 0 {
+		t.Errorf("%v allocations a tick", n)
+	}
+	if gs.GameOver {
+		t.Error("the game ended")
+	}
+}
+
+// BenchmarkUpdate measures a tick of a game under way, which shouldn't allocate anything
+func BenchmarkUpdate(b *testing.B) {
+	gs := underWay(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		playOn(gs)
+	}
+}
@@ -0,0 +1,229 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"image/color"
+	"math"
+)
+
+type TrollState int
+
+const (
+	LURKING  TrollState = iota
+	REACHING TrollState = iota
+	GRABBING TrollState = iota
+	SINKING  TrollState = iota
+)
+
+// prey is a mount the troll can drag down into the lava
+type prey interface {
+	mountSprite() *MountSprite
+	catchable() bool
+	burn(gs *GameState)
+}
+
+// Troll is the Lava Troll's hand. Once the bridges have burnt away it lurks under the pit,
+// reaching up for anything that flies too low and dragging it into the lava. Only players
+// can flap hard enough to break free.
+type Troll struct {
+	*Sprite
+	state  TrollState
+	prey   prey
+	flaps  int
+	rested Tick
+}
+
+func MakeTroll(ss *Sheet) *Troll {
+	t := &Troll{
+		Sprite: MakeSprite(ss.Troll),
+	}
+	t.setFrame(ss, t.Images[0])
+	return t
+}
+
+// Hidden is true while the hand is under the lava
+func (t *Troll) Hidden() bool {
+	return t.state == LURKING
+}
+
+func (t *Troll) Update(gs *GameState) {
+	now := gs.Clock.Now()
+	switch t.state {
+	case LURKING:
+		if gs.BridgesStanding() || now.Before(t.rested) {
+			return
+		}
+		if target := t.findPrey(gs); target != nil {
+			t.prey = target
+			t.state = REACHING
+			t.SetPos(target.mountSprite().X, gs.Level.LavaY+float64(t.Height)/2)
+		}
+	case REACHING:
+		m := t.prey.mountSprite()
+		if !t.prey.catchable() || !gs.overLava(m.Sprite) || t.Y < gs.Level.LavaY-app.TrollReach {
+			// it got away
+			t.release(gs)
+			break
+		}
+		t.X = m.X
+		t.Y -= app.TrollRise
+		t.Frame = int(now/8) % 2
+		t.setFrame(gs.Sheet, t.Images[t.Frame])
+		if t.Collides(gs, m.Sprite) {
+			t.state = GRABBING
+			t.flaps = 0
+			gs.PlaySound(app.WhompSound)
+		}
+	case GRABBING:
+		t.grabbing(gs)
+	case SINKING:
+		t.Y += app.TrollRise
+		if t.Y-float64(t.Height)/2 > gs.Level.LavaY {
+			t.state = LURKING
+			t.rested = now.Add(app.TrollRestTicks)
+		}
+	}
+}
+
+// grabbing pulls the mount down a little every tick. Each flap the player manages lifts
+// them back up, and enough of them breaks the troll's grip.
+func (t *Troll) grabbing(gs *GameState) {
+	m := t.prey.mountSprite()
+	if !t.prey.catchable() {
+		t.release(gs)
+		return
+	}
+	if _, ok := t.prey.(*Player); ok && m.lastFlap == gs.Clock.Now() {
+		t.Y -= app.TrollLift
+		t.flaps++
+		if t.flaps >= app.TrollEscapeFlaps {
+			m.Vy = -1
+			t.release(gs)
+			return
+		}
+	}
+	t.Y += app.TrollPull
+	t.Frame = 2
+	t.setFrame(gs.Sheet, t.Images[t.Frame])
+
+	// hold the mount by its feet
+	m.X = t.X
+	m.Y = t.Y - float64(t.Height+m.Height)/2 + 4
+	m.Vy = 0
+	m.xSpeed = 0
+	if gs.inLava(m.Sprite) {
+		t.prey.burn(gs)
+		t.release(gs)
+	}
+}
+
+func (t *Troll) release(gs *GameState) {
+	t.prey = nil
+	t.state = SINKING
+	t.Frame = 0
+	t.setFrame(gs.Sheet, t.Images[t.Frame])
+}
+
+// findPrey looks for a player or an enemy flying low over the pit
+func (t *Troll) findPrey(gs *GameState) prey {
+	for _, p := range gs.Players {
+		if gs.withinReach(p) {
+			return p
+		}
+	}
+	for _, b := range gs.Buzzards {
+		if gs.withinReach(b) {
+			return b
+		}
+	}
+	return nil
+}
+
+// withinReach is whether the troll can grab the mount from the lava
+func (gs *GameState) withinReach(c prey) bool {
+	m := c.mountSprite()
+	return c.catchable() && gs.overLava(m.Sprite) && m.Y+float64(m.Height)/2 > gs.Level.LavaY-app.TrollReach
+}
+
+func (p *Player) catchable() bool {
+	return p.state == MOUNTED
+}
+
+func (p *Player) burn(gs *GameState) {
+	p.state = DEAD
+	gs.PlaySound(app.LavaSound)
+}
+
+func (b *Buzzard) catchable() bool {
+	return b.state == MOUNTED && b.Alive
+}
+
+func (b *Buzzard) burn(gs *GameState) {
+	b.state = DEAD
+	b.Alive = false
+	gs.PlaySound(app.LavaSound)
+}
+
+// inLava is true once the bottom of the sprite has sunk below the surface of the lava
+func (gs *GameState) inLava(s *Sprite) bool {
+	return s.Y+float64(s.Height)/2 > gs.Level.LavaY
+}
+
+// overLava is true when there's nothing but the pit below the sprite, on whichever screen
+// of the world it's over
+func (gs *GameState) overLava(s *Sprite) bool {
+	left, right := gs.Level.pit()
+	x := math.Mod(s.X, app.ScreenWidth)
+	if x < 0 {
+		x += app.ScreenWidth
+	}
+	return x < left || x > right
+}
+
+// burnBridges eats away at the bridges over the pit a column at a time once the wave they
+// burn on has started, from the screen edges in toward the bottom cliff
+func (gs *GameState) burnBridges() {
+	if gs.Wave.Number < app.BridgeWave || gs.Wave.ShowBanner(gs.Clock.Now()) {
+		return
+	}
+	if gs.Clock.Now()%app.BridgeBurnTicks != 0 {
+		return
+	}
+	for _, c := range gs.Cliffs {
+		if c.bridges {
+			c.burn()
+		}
+	}
+}
+
+func (c *Cliff) burnColumn(x int) {
+	for y := 0; y < c.Height; y++ {
+		c.image.SetRGBA(x, y, color.RGBA{})
+	}
+	c.mask.clearColumn(x)
+	c.version++
+}
+
+// BridgesStanding is true until the lava has burnt away both bridges
+func (gs *GameState) BridgesStanding() bool {
+	for _, c := range gs.Cliffs {
+		if c.bridges {
+			return true
+		}
+	}
+	return false
+}
+
+// burn eats the next column off the end of each bridge, straight out of the cliff's picture
+// and its mask
+func (c *Cliff) burn() {
+	left, right := c.reach[0], c.reach[1]
+	if c.burnt < left {
+		c.burnColumn(c.burnt)
+	}
+	if c.burnt < right {
+		c.burnColumn(c.Width - 1 - c.burnt)
+	}
+	c.burnt++
+	c.bridges = c.burnt < max(left, right)
+}
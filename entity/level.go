@@ -0,0 +1,188 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/assets/levels"
+	"hash/fnv"
+	"image"
+	"os"
+)
+
+// Region is a rectangle of the sprite sheet
+type Region struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func (r Region) rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+}
+
+// SpawnPad is a spot on a ledge where riders materialize
+type SpawnPad struct {
+	X       int  `json:"x"`       // from the left edge of the ledge
+	Players bool `json:"players"` // players spawn here as well as enemies
+}
+
+// LevelCliff is a ledge of the arena. A ledge with a bridge is the bottom cliff, with the
+// bridge tiled out either side of it over the lava to the edges of the screen.
+type LevelCliff struct {
+	Name    string    `json:"name"`
+	Sprite  Region    `json:"sprite"`
+	X       float64   `json:"x"`
+	Y       float64   `json:"y"`
+	Bridge  *Region   `json:"bridge,omitempty"`
+	Spawn   *SpawnPad `json:"spawn,omitempty"`
+	Burn    int       `json:"burn,omitempty"`    // wave of each cycle the ledge burns away on
+	Restore int       `json:"restore,omitempty"` // and the wave it comes back on
+}
+
+// Level is the layout of an arena: its ledges and spawn pads, the lanes enemies fly along
+// and the lava underneath it all
+type Level struct {
+	Name   string       `json:"name"`
+	Wrap   bool         `json:"wrap"`  // riders go off one side and come back on the other
+	LavaY  float64      `json:"lavaY"` // surface of the lava
+	Cycle  int          `json:"cycle"` // ledges burn away and come back on a cycle this many waves long
+	Lanes  []int        `json:"lanes"`
+	Cliffs []LevelCliff `json:"cliffs"`
+}
+
+// DefaultLevel is the arena from the arcade
+func DefaultLevel() *Level {
+	l, err := ParseLevel(levels.Classic_json)
+	if err != nil {
+		panic(fmt.Errorf("embedded level is invalid: %w", err))
+	}
+	return l
+}
+
+func LoadLevel(path string) (*Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLevel(data)
+}
+
+// ParseLevel decodes a level from JSON and checks it can be played
+func ParseLevel(data []byte) (*Level, error) {
+	l := &Level{}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Validate reports every problem with the level rather than just the first
+func (l *Level) Validate() error {
+	var errs []error
+	sheet := sheetBounds()
+
+	if l.LavaY <= 0 || l.LavaY > app.ScreenHeight {
+		errs = append(errs, fmt.Errorf("lava must be on screen, not at %v", l.LavaY))
+	}
+	if len(l.Lanes) == 0 {
+		errs = append(errs, errors.New("no lanes for enemies to fly along"))
+	}
+	for _, lane := range l.Lanes {
+		if lane <= 0 || float64(lane) >= l.LavaY {
+			errs = append(errs, fmt.Errorf("lane %d must be between the top of the screen and the lava", lane))
+		}
+	}
+
+	bridges, players := 0, 0
+	for i, c := range l.Cliffs {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("cliff %d", i)
+		}
+		if c.Sprite.W <= 0 || c.Sprite.H <= 0 || !c.Sprite.rect().In(sheet) {
+			errs = append(errs, fmt.Errorf("%s: sprite %+v isn't on the sprite sheet", name, c.Sprite))
+		}
+		if c.Bridge != nil {
+			bridges++
+			if c.Bridge.W <= 0 || c.Bridge.H <= 0 || !c.Bridge.rect().In(sheet) {
+				errs = append(errs, fmt.Errorf("%s: bridge %+v isn't on the sprite sheet", name, *c.Bridge))
+			}
+			if c.X < 0 || c.X+float64(c.Sprite.W) > app.ScreenWidth {
+				errs = append(errs, fmt.Errorf("%s: a cliff with bridges must be on screen", name))
+			}
+			if c.Burn != 0 {
+				errs = append(errs, fmt.Errorf("%s: a cliff with bridges can't burn away", name))
+			}
+		}
+		if c.Spawn != nil {
+			if c.Spawn.X < 0 || c.Spawn.X >= c.Sprite.W {
+				errs = append(errs, fmt.Errorf("%s: spawn pad at %d is off the ledge", name, c.Spawn.X))
+			}
+			if c.Spawn.Players {
+				players++
+			}
+		}
+		if c.Burn != 0 || c.Restore != 0 {
+			if c.Burn < 1 || c.Restore <= c.Burn || c.Restore > l.Cycle+1 {
+				errs = append(errs, fmt.Errorf("%s: must burn and restore within the cycle of %d waves", name, l.Cycle))
+			}
+		}
+	}
+	if bridges != 1 {
+		errs = append(errs, fmt.Errorf("need exactly one bottom cliff with bridges, not %d", bridges))
+	}
+	if players == 0 {
+		errs = append(errs, errors.New("no spawn pads for players"))
+	}
+	return errors.Join(errs...)
+}
+
+// Hash identifies the layout of the level, so replays and high scores are only taken to
+// belong to it when the arena is the same. The name doesn't come into it.
+func (l *Level) Hash() uint64 {
+	layout := *l
+	layout.Name = ""
+	// a level always encodes
+	data, _ := json.Marshal(layout)
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// pit is how far the lava reaches in from each side of the screen, up to the bottom cliff
+func (l *Level) pit() (float64, float64) {
+	for _, c := range l.Cliffs {
+		if c.Bridge != nil {
+			return c.X, c.X + float64(c.Sprite.W)
+		}
+	}
+	return app.ScreenWidth, 0
+}
+
+// makeCliffs builds the ledges of the level out of the sprite sheet, laid out once for
+// each screen of the world
+func (l *Level) makeCliffs(ss *Sheet, screens int) []*Cliff {
+	var result []*Cliff
+	for i := 0; i < screens; i++ {
+		offset := float64(i * app.ScreenWidth)
+		for _, lc := range l.Cliffs {
+			var c *Cliff
+			if lc.Bridge != nil {
+				c = MakeBottomCliff(ss.Region(lc.Sprite), ss.Region(*lc.Bridge), lc.X, lc.Y)
+				c.X += offset
+			} else {
+				c = MakeCliff(ss.Region(lc.Sprite), lc.X+offset, lc.Y)
+			}
+			c.spawn = lc.Spawn
+			c.vanish = vanishing{burn: lc.Burn, restore: lc.Restore}
+			result = append(result, c)
+		}
+	}
+	return result
+}
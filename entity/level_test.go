@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultLevelIsValid(t *testing.T) {
+	if err := DefaultLevel().Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoil func(l *Level)
+		want  string
+	}{
+		{"lava off screen", func(l *Level) { l.LavaY = 0 }, "lava must be on screen"},
+		{"no lanes", func(l *Level) { l.Lanes = nil }, "no lanes"},
+		{"lane in the lava", func(l *Level) { l.Lanes = append(l.Lanes, int(l.LavaY)) }, "between the top of the screen and the lava"},
+		{"sprite off the sheet", func(l *Level) { l.Cliffs[1].Sprite.X = -10 }, "isn't on the sprite sheet"},
+		{"no bottom cliff", func(l *Level) { l.Cliffs[0].Bridge = nil }, "exactly one bottom cliff"},
+		{"burning bottom cliff", func(l *Level) { l.Cliffs[0].Burn, l.Cliffs[0].Restore = 2, 3 }, "can't burn away"},
+		{"spawn pad off the ledge", func(l *Level) { l.Cliffs[2].Spawn.X = l.Cliffs[2].Sprite.W }, "off the ledge"},
+		{"restored before burnt", func(l *Level) { l.Cliffs[1].Restore = l.Cliffs[1].Burn }, "within the cycle"},
+		{"no player pads", func(l *Level) {
+			for _, c := range l.Cliffs {
+				if c.Spawn != nil {
+					c.Spawn.Players = false
+				}
+			}
+		}, "no spawn pads for players"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := DefaultLevel()
+			tt.spoil(l)
+			err := l.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error about %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEverything(t *testing.T) {
+	l := DefaultLevel()
+	l.LavaY = 0
+	l.Lanes = nil
+	err := l.Validate()
+	if err == nil || !strings.Contains(err.Error(), "lava") || !strings.Contains(err.Error(), "lanes") {
+		t.Errorf("got %v, want both problems", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if _, err := ParseLevel([]byte(`{"name": "broken"`)); err == nil {
+		t.Error("parsed a truncated level")
+	}
+	if _, err := ParseLevel([]byte(`{"name": "empty"}`)); err == nil {
+		t.Error("parsed a level with nothing in it")
+	}
+}
+
+func TestHashIsOfTheLayout(t *testing.T) {
+	l := DefaultLevel()
+	renamed := DefaultLevel()
+	renamed.Name = "Classic copy"
+	if l.Hash() != renamed.Hash() {
+		t.Error("renaming the level changed its hash")
+	}
+	moved := DefaultLevel()
+	moved.Cliffs[1].X++
+	if l.Hash() == moved.Hash() {
+		t.Error("moving a ledge didn't change the hash")
+	}
+}
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"image"
+)
+
+// Mask is a 1-bit collision mask with a bit set wherever its source image is opaque. Each row
+// starts a fresh run of words, with the leftmost pixel in the lowest bit, so two masks can be
+// checked against each other 64 pixels of a row at a time.
+type Mask struct {
+	Width  int
+	Height int
+	stride int // words per row
+	bits   []uint64
+}
+
+func MaskOf(img image.Image) *Mask {
+	b := img.Bounds()
+	m := &Mask{
+		Width:  b.Dx(),
+		Height: b.Dy(),
+		stride: (b.Dx() + 63) / 64,
+	}
+	m.bits = make([]uint64, m.stride*m.Height)
+	rgba, _ := img.(*image.RGBA)
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			if opaque(img, rgba, b.Min.X+x, b.Min.Y+y) {
+				m.bits[y*m.stride+x/64] |= 1 << (x % 64)
+			}
+		}
+	}
+	return m
+}
+
+// opaque is whether the pixel isn't see-through, reading it straight out of rgba when img
+// is one
+func opaque(img image.Image, rgba *image.RGBA, x, y int) bool {
+	if rgba != nil {
+		return rgba.Pix[rgba.PixOffset(x, y)+3] != 0
+	}
+	_, _, _, a := img.At(x, y).RGBA()
+	return a != 0
+}
+
+func (m *Mask) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return false
+	}
+	return m.bits[y*m.stride+x/64]&(1<<(x%64)) != 0
+}
+
+// clearColumn clears every pixel in column x
+func (m *Mask) clearColumn(x int) {
+	for y := 0; y < m.Height; y++ {
+		m.bits[y*m.stride+x/64] &^= 1 << (x % 64)
+	}
+}
+
+// span is the 64 pixels of row y starting at x, the first in the lowest bit. Those past the
+// end of the row are clear.
+func (m *Mask) span(x, y int) uint64 {
+	row := m.bits[y*m.stride : (y+1)*m.stride]
+	i, shift := x/64, x%64
+	s := row[i] >> shift
+	if shift > 0 && i+1 < len(row) {
+		s |= row[i+1] << (64 - shift)
+	}
+	return s
+}
+
+// Overlaps is whether m with its top left at a and o with its top left at b have a pixel set
+// in the same place anywhere within r
+func (m *Mask) Overlaps(a image.Point, o *Mask, b image.Point, r image.Rectangle) bool {
+	r = r.Intersect(image.Rectangle{Min: a, Max: a.Add(image.Pt(m.Width, m.Height))})
+	r = r.Intersect(image.Rectangle{Min: b, Max: b.Add(image.Pt(o.Width, o.Height))})
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x += 64 {
+			within := ^uint64(0) >> (64 - min(64, r.Max.X-x))
+			if m.span(x-a.X, y-a.Y)&o.span(x-b.X, y-b.Y)&within != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// base is one past the lowest row with anything set, or zero if nothing is
+func (m *Mask) base() int {
+	for y := m.Height - 1; y >= 0; y-- {
+		for x := 0; x < m.Width; x++ {
+			if m.At(x, y) {
+				return y + 1
+			}
+		}
+	}
+	return 0
+}
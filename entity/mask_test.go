@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// randomImage is a picture with about the given share of its pixels opaque
+func randomImage(r *rand.Rand, w, h int, share float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if r.Float64() < share {
+				img.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// overlapsByPixel is what Overlaps works out, checked a pixel at a time
+func overlapsByPixel(m *Mask, a image.Point, o *Mask, b image.Point, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if m.At(x-a.X, y-a.Y) && o.At(x-b.X, y-b.Y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestMaskOf(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	img := randomImage(r, 130, 7, 0.5)
+	// a picture cut from the middle of a bigger one starts where it was cut from
+	sub := img.SubImage(image.Rect(3, 2, 100, 7)).(*image.RGBA)
+	for _, src := range []*image.RGBA{img, sub} {
+		m := MaskOf(src)
+		b := src.Bounds()
+		if m.Width != b.Dx() || m.Height != b.Dy() {
+			t.Fatalf("mask is %dx%d, want %dx%d", m.Width, m.Height, b.Dx(), b.Dy())
+		}
+		for y := 0; y < m.Height; y++ {
+			for x := 0; x < m.Width; x++ {
+				if want := src.RGBAAt(b.Min.X+x, b.Min.Y+y).A != 0; m.At(x, y) != want {
+					t.Fatalf("%v: pixel %d,%d is %v, want %v", b, x, y, m.At(x, y), want)
+				}
+			}
+		}
+	}
+}
+
+func TestOverlapsMatchesPixels(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	widths := []int{1, 7, 63, 64, 65, 100, 128, 130, 200}
+	for i := 0; i < 5000; i++ {
+		// sparse enough that they often only just touch, or miss
+		share := []float64{0.005, 0.02, 0.2}[i%3]
+		m := MaskOf(randomImage(r, widths[r.Intn(len(widths))], 1+r.Intn(8), share))
+		o := MaskOf(randomImage(r, widths[r.Intn(len(widths))], 1+r.Intn(8), share))
+		a := image.Pt(r.Intn(300)-150, r.Intn(30)-15)
+		b := a.Add(image.Pt(r.Intn(o.Width+m.Width)-o.Width, r.Intn(8)-4))
+		within := image.Rect(-400, -100, 400, 100)
+		if i%2 == 1 {
+			// just part of where they are, like the part of a sprite over the seam
+			within = image.Rect(a.X+r.Intn(m.Width+1)-10, a.Y-5, a.X+r.Intn(m.Width+1)+10, a.Y+r.Intn(m.Height+1))
+		}
+
+		want := overlapsByPixel(m, a, o, b, within)
+		if got := m.Overlaps(a, o, b, within); got != want {
+			t.Fatalf("%dx%d at %v and %dx%d at %v within %v: overlap %v, want %v",
+				m.Width, m.Height, a, o.Width, o.Height, b, within, got, want)
+		}
+		if got := o.Overlaps(b, m, a, within); got != want {
+			t.Fatalf("%dx%d at %v and %dx%d at %v within %v the other way round: overlap %v, want %v",
+				o.Width, o.Height, b, m.Width, m.Height, a, within, got, want)
+		}
+	}
+}
+
+func TestOverlapsFarBits(t *testing.T) {
+	// a single pixel past the first word of a row, lined up with one of the other mask's
+	img := image.NewRGBA(image.Rect(0, 0, 150, 1))
+	img.SetRGBA(140, 0, color.RGBA{A: 255})
+	m := MaskOf(img)
+	dot := MaskOf(randomImage(rand.New(rand.NewSource(1)), 1, 1, 1))
+	everywhere := image.Rect(-1000, -1000, 1000, 1000)
+
+	for _, at := range []image.Point{{0, 0}, {-70, 3}, {-200, -1}} {
+		hit := at.Add(image.Pt(140, 0))
+		if !m.Overlaps(at, dot, hit, everywhere) {
+			t.Errorf("mask at %v missed the dot at %v", at, hit)
+		}
+		if m.Overlaps(at, dot, hit.Add(image.Pt(1, 0)), everywhere) {
+			t.Errorf("mask at %v hit the dot beside its pixel", at)
+		}
+	}
+}
+
+func TestClearColumn(t *testing.T) {
+	m := MaskOf(randomImage(rand.New(rand.NewSource(1)), 100, 5, 1))
+	m.clearColumn(70)
+	for y := 0; y < m.Height; y++ {
+		if m.At(70, y) || !m.At(69, y) || !m.At(71, y) {
+			t.Fatalf("row %d around the cleared column: %v %v %v", y, m.At(69, y), m.At(70, y), m.At(71, y))
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 70, 10))
+	m := MaskOf(img)
+	if m.base() != 0 {
+		t.Errorf("base of an empty mask is %d, want 0", m.base())
+	}
+	img.SetRGBA(66, 6, color.RGBA{A: 255})
+	if m = MaskOf(img); m.base() != 7 {
+		t.Errorf("base is %d, want 7", m.base())
+	}
+}
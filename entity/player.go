@@ -2,12 +2,7 @@ package entity
 
 import (
 	"github.com/depsypher/gojoust/app"
-	"github.com/depsypher/gojoust/assets/audio"
-	"github.com/hajimehoshi/ebiten/v2"
 	"image"
-	"log"
-	"math/rand"
-	"time"
 )
 
 type PlayerState int
@@ -17,31 +12,41 @@ const (
 	MOUNTED   PlayerState = iota
 	UNMOUNTED PlayerState = iota
 	DEAD      PlayerState = iota
+
+	// REMOUNTING is a riderless buzzard flying in to pick up a rider hatched from an egg
+	REMOUNTING PlayerState = iota
 )
 
 type Player struct {
 	*MountSprite
-	rider       *ebiten.Image
-	lastAnimate time.Time
-	lastAccel   time.Time
-	skid        time.Time
+	Number      int
+	rider       *image.RGBA
+	lastAnimate Tick
+	lastAccel   Tick
+	skid        Tick
 	walkStep    bool
 	state       PlayerState
+	eggsInARow  int
+	Score       int
+	Lives       int
 }
 
-func MakePlayer(ss *Sheet) *Player {
+// MakePlayer makes the numbered player, counting from zero. Player one rides an ostrich and
+// player two a stork.
+func MakePlayer(ss *Sheet, number int) *Player {
+	mounts := [][]*image.RGBA{ss.Ostrich, ss.Stork}
+	riders := []*image.RGBA{ss.P1Rider, ss.P2Rider}
 	return &Player{
-		MountSprite: MakeMountSprite(ss.Ostrich),
-		rider:       ss.P1Rider,
-		lastAnimate: time.Now(),
-		lastAccel:   time.Now(),
-		skid:        time.Time{},
+		MountSprite: MakeMountSprite(mounts[number%len(mounts)]),
+		Number:      number,
+		rider:       riders[number%len(riders)],
+		Lives:       app.StartingLives,
 	}
 }
 
-func (p *Player) Draw(screen *ebiten.Image) {
-	s := p.Sprite
-	s.DrawSprite(screen)
+// intent is what the player's controller wants to do this tick
+func (p *Player) intent(gs *GameState) Intent {
+	return gs.Intents[p.Number]
 }
 
 func (p *Player) Update(gs *GameState) {
@@ -58,61 +63,68 @@ func (p *Player) Update(gs *GameState) {
 }
 
 func (p *Player) spawning(gs *GameState) {
-	if time.Now().Before(p.lastAnimate.Add(time.Millisecond * time.Duration(30))) {
+	if gs.Clock.Now().Before(p.lastAnimate.Add(app.SpawnAnimTicks)) {
 		return
 	}
 	if p.spawn <= 20 {
 		// emerging
-		p.buildSpawn(p, p.spawn)
+		p.buildSpawn(gs, p, p.spawn)
 		p.spawn += 1
 		if p.spawn == 20 {
-			if err := gs.Sounds[audio.EnergizeSound].Play(gs.SoundOn); err != nil {
-				log.Fatal("Error playing sound", err)
-			}
+			gs.PlaySound(app.EnergizeSound)
 		}
 	} else if p.spawn < 100 {
 		// energizing/waiting
-		if gs.Keys[app.FlapButton] || gs.Keys[app.LeftButton] || gs.Keys[app.RightButton] {
+		if p.intent(gs).Any() {
 			p.state = MOUNTED
-			p.image = p.buildMount()
+			p.setFrame(gs.Sheet, p.buildMount(gs))
 			p.spawn = 0
 			p.Vy = 1
-			gs.Sounds[audio.EnergizeSound].Stop()
+			gs.StopSound(app.EnergizeSound)
 		} else {
-			p.image = p.buildMount()
+			p.setFrame(gs.Sheet, p.buildMount(gs))
 		}
 		p.spawn += 1
 	} else {
 		p.state = MOUNTED
-		p.image = p.buildMount()
+		p.setFrame(gs.Sheet, p.buildMount(gs))
 		p.spawn = 0
 		p.Vy = 1
 	}
-	p.lastAnimate = time.Now()
+	p.lastAnimate = gs.Clock.Now()
 }
 
 func (p *Player) mounted(gs *GameState) {
 	p.walkInput(gs)
 	p.flapInput(gs)
 	p.velocity()
+	if gs.inLava(p.Sprite) {
+		p.burn(gs)
+		return
+	}
 
 	aboveCliff := cliffCollision(gs, p)
 	buzzardCollision(gs, p)
+	playerCollision(gs, p)
+	eggCollision(gs, p)
+	pterodactylCollision(gs, p)
 
 	p.walkAnimation(gs)
-	p.Wrap()
+	if p.Wrap(gs) {
+		p.xSpeed = 0
+	}
 	if !aboveCliff {
 		p.walking = false
 	}
 
-	p.image = p.buildMount()
+	p.setFrame(gs.Sheet, p.buildMount(gs))
 }
 
 func cliffCollision(gs *GameState, p *Player) bool {
 	aboveCliff := false
 	for _, c := range gs.CliffAsSprites() {
 		p.Y += 1
-		if p.Collides(c) {
+		if p.Collides(gs, c) {
 			p.Y -= 1
 			if p.bounce(gs, c) {
 				aboveCliff = true
@@ -126,21 +138,19 @@ func cliffCollision(gs *GameState, p *Player) bool {
 
 func buzzardCollision(gs *GameState, p *Player) {
 	for _, enemy := range gs.Buzzards {
-		if enemy.state != SPAWNING && enemy.Alive && p.Collides(enemy.Sprite) {
+		if enemy.state == MOUNTED && enemy.Alive && p.Collides(gs, enemy.Sprite) {
 			py := int(p.centerY())
 			by := int(enemy.centerY())
 			if py < by {
 				p.Vy = -0.5
 				p.Y = enemy.Y - float64(enemy.Height)*0.6
 				enemy.state = UNMOUNTED
-				if err := gs.Sounds[audio.HitSound].Play(gs.SoundOn); err != nil {
-					log.Fatal("Error playing sound", err)
-				}
+				gs.Eggs = append(gs.Eggs, MakeEgg(gs.Sheet, enemy.Class, enemy.X, enemy.Y, enemy.Vx, -0.5))
+				p.award(gs, gs.Wave.Tactics[enemy.Class].Points)
+				gs.PlaySound(app.HitSound)
 			} else if py > by {
 				p.state = UNMOUNTED
-				if err := gs.Sounds[audio.HitSound].Play(gs.SoundOn); err != nil {
-					log.Fatal("Error playing sound", err)
-				}
+				gs.PlaySound(app.HitSound)
 			} else {
 				p.bounce(gs, enemy.Sprite)
 				enemy.bounce(gs, p.Sprite)
@@ -149,35 +159,70 @@ func buzzardCollision(gs *GameState, p *Player) {
 	}
 }
 
+// playerCollision jousts the player against the other players by the same rule as enemies,
+// the higher lance wins, unless they're playing co-op. Each pair is only checked once a
+// tick, by the lower numbered player.
+func playerCollision(gs *GameState, p *Player) {
+	for _, other := range gs.Players[p.Number+1:] {
+		if other.state == MOUNTED && p.Collides(gs, other.Sprite) {
+			py := int(p.centerY())
+			oy := int(other.centerY())
+			if gs.Mode == Coop {
+				p.bounce(gs, other.Sprite)
+				other.bounce(gs, p.Sprite)
+			} else if py < oy {
+				p.unhorse(gs, other)
+			} else if py > oy {
+				other.unhorse(gs, p)
+			} else {
+				p.bounce(gs, other.Sprite)
+				other.bounce(gs, p.Sprite)
+			}
+		}
+	}
+}
+
+// unhorse knocks the other player off their mount
+func (p *Player) unhorse(gs *GameState, other *Player) {
+	p.Vy = -0.5
+	other.state = UNMOUNTED
+	gs.PlaySound(app.HitSound)
+
+	w := gs.Wave
+	w.betrayed = true
+	if w.Kind == GladiatorWave && !w.gladiator {
+		w.gladiator = true
+		p.award(gs, app.GladiatorPoints)
+	}
+}
+
 func (p *Player) bounce(gs *GameState, collider *Sprite) bool {
 	above := false
 	playBump := false
-	if p.Y < collider.centerY() && xBetween(p.X, collider.rect(), 3) {
+	if p.Y < collider.centerY() && xBetween(p.X, gs.nearRect(p.Sprite, collider), 3) {
 		// player is above
 		p.Vy = 0.5
 		p.Y = collider.Y - float64(p.Height/2)
 		p.walking = true
 		above = true
-	} else if p.Y-p.Vy > collider.Y && xBetween(p.X, collider.rect(), 0) {
+	} else if p.Y-p.Vy > collider.Y && xBetween(p.X, gs.nearRect(p.Sprite, collider), 0) {
 		// player is below
 		p.Y += 3
 		p.Vy = 0.5
 		playBump = true
-	} else if p.centerX() < collider.centerX() {
+	} else if gs.dx(p.centerX(), collider.centerX()) > 0 {
 		// player is to left
 		p.X -= 5
 		p.xSpeed = -2
 		playBump = true
-	} else if p.centerX() > collider.centerX() {
+	} else if gs.dx(p.centerX(), collider.centerX()) < 0 {
 		// player is to right
 		p.X += 5
 		p.xSpeed = 2
 		playBump = true
 	}
 	if playBump {
-		if err := gs.Sounds[audio.BumpSound].Play(gs.SoundOn); err != nil {
-			log.Fatal("Error playing sound", err)
-		}
+		gs.PlaySound(app.BumpSound)
 	}
 	return above
 }
@@ -187,15 +232,16 @@ func xBetween(x float64, rect image.Rectangle, grace int) bool {
 }
 
 func (p *Player) walkInput(gs *GameState) {
-	now := time.Now()
-	canAccel := now.After(p.lastAccel.Add(time.Millisecond * time.Duration(120)))
+	in := p.intent(gs)
+	now := gs.Clock.Now()
+	canAccel := !now.Before(p.lastAccel.Add(app.AccelTicks))
 	if !p.skid.IsZero() {
 		if p.skid.After(now) {
 			if p.xSpeed != 0 {
 				speed := 4
-				if p.skid.Sub(now) < (time.Millisecond * time.Duration(app.SkidMillis/2)) {
+				if p.skid.Sub(now) < app.SkidTicks/2 {
 					speed = 2
-				} else if p.skid.Sub(now) < (time.Millisecond * time.Duration(app.SkidMillis/2)) {
+				} else if p.skid.Sub(now) < app.SkidTicks/2 {
 					speed = 3
 				}
 				if p.xSpeed > 0 {
@@ -206,33 +252,31 @@ func (p *Player) walkInput(gs *GameState) {
 			}
 		} else {
 			p.xSpeed = 0
-			p.lastAccel = time.Now()
-			p.skid = time.Time{}
-		}
-	} else if p.walking && (p.xSpeed > 3 && gs.Keys[app.LeftButton] || (p.xSpeed < -3 && gs.Keys[app.RightButton])) {
-		p.skid = now.Add(time.Millisecond * time.Duration(app.SkidMillis))
-		if err := gs.Sounds[audio.SkidSound].Play(gs.SoundOn); err != nil {
-			log.Fatal("Error playing sound", err)
+			p.lastAccel = now
+			p.skid = 0
 		}
-	} else if gs.Keys[app.LeftButton] {
+	} else if p.walking && (p.xSpeed > 3 && in.Left || (p.xSpeed < -3 && in.Right)) {
+		p.skid = now.Add(app.SkidTicks)
+		gs.PlaySound(app.SkidSound)
+	} else if in.Left {
 		if p.walking {
 			if canAccel {
 				p.Vx = -1
 				if p.xSpeed > -4 {
 					p.xSpeed -= 1
-					p.lastAccel = time.Now()
+					p.lastAccel = now
 				}
 			}
 		} else {
 			p.FacingRight = false
 		}
-	} else if gs.Keys[app.RightButton] && canAccel {
+	} else if in.Right && canAccel {
 		if p.walking {
 			if canAccel {
 				p.Vx = 1
 				if p.xSpeed < 4 {
 					p.xSpeed += 1
-					p.lastAccel = time.Now()
+					p.lastAccel = now
 				}
 			}
 		} else {
@@ -242,31 +286,29 @@ func (p *Player) walkInput(gs *GameState) {
 }
 
 func (p *Player) flapInput(gs *GameState) {
-	if gs.Keys[app.FlapButton] {
-		p.skid = time.Time{}
+	in := p.intent(gs)
+	if in.Flap {
+		p.skid = 0
 		if p.flap == 0 {
-			if gs.Keys[app.LeftButton] {
+			if in.Left {
 				p.xSpeed -= 1
 			}
-			if gs.Keys[app.RightButton] {
+			if in.Right {
 				p.xSpeed += 1
 			}
 			p.Vy = -0.4
 			p.flap = 2
-			gs.Sounds.StopSounds()
-			if err := gs.Sounds[audio.FlapDnSound].Play(gs.SoundOn); err != nil {
-				log.Fatal("Error playing sound", err)
-			}
+			p.lastFlap = gs.Clock.Now()
+			gs.StopSounds()
+			gs.PlaySound(app.FlapDnSound)
 		} else {
 			p.flap = 1
 		}
 		p.walking = false
 	} else {
 		if p.flap == 1 {
-			gs.Sounds.StopSounds()
-			if err := gs.Sounds[audio.FlapUpSound].Play(gs.SoundOn); err != nil {
-				log.Fatal("Error playing sound", err)
-			}
+			gs.StopSounds()
+			gs.PlaySound(app.FlapUpSound)
 		}
 		p.flap = 0
 	}
@@ -276,28 +318,26 @@ func (p *Player) walkAnimation(gs *GameState) {
 	if p.walking {
 		if p.xSpeed == 0 {
 			p.Frame = 3
-			gs.Sounds.StopSounds()
+			gs.StopSounds()
 		} else {
 			if !p.skid.IsZero() {
 				p.Frame = 4
 			} else {
 				nextFrame := app.WalkAnimSpeed[app.Abs(p.xSpeed)-1]
-				if time.Now().After(p.lastAnimate.Add(nextFrame)) {
+				if !gs.Clock.Now().Before(p.lastAnimate.Add(nextFrame)) {
 					p.Frame += 1
 					if p.Frame > 3 {
 						p.Frame = 0
 					}
 					if p.Frame == 2 {
-						snd := audio.Walk1Sound
+						snd := app.Walk1Sound
 						if p.walkStep {
-							snd = audio.Walk2Sound
-						}
-						if err := gs.Sounds[snd].Play(gs.SoundOn); err != nil {
-							log.Fatal("Error playing sound", err)
+							snd = app.Walk2Sound
 						}
+						gs.PlaySound(snd)
 						p.walkStep = !p.walkStep
 					}
-					p.lastAnimate = time.Now()
+					p.lastAnimate = gs.Clock.Now()
 				}
 			}
 		}
@@ -305,67 +345,75 @@ func (p *Player) walkAnimation(gs *GameState) {
 }
 
 func (p *Player) unmounted(gs *GameState) {
-	if p.X < app.ScreenWidth/2 {
+	if p.X < gs.Width()/2 {
 		p.FacingRight = false
 		p.xSpeed = -3
 	} else {
 		p.FacingRight = true
 		p.xSpeed = 3
 	}
-	p.doFlap()
-	p.image = p.buildMount()
+	p.doFlap(gs, app.FlapTicks)
+	p.setFrame(gs.Sheet, p.buildMount(gs))
 	p.velocity()
-	if p.X < -float64(p.Width) || p.X > app.ScreenWidth+float64(p.Width/2) {
+	if p.X < -float64(p.Width) || p.X > gs.Width()+float64(p.Width/2) {
 		p.state = DEAD
 	}
 }
 
 func (p *Player) dead(gs *GameState) {
-	sp := app.SpawnPoints[rand.Intn(3)]
-	p.SetPos(float64(sp[0]), float64(sp[1]))
+	if !p.Alive {
+		return
+	}
+	if p.Lives == 0 {
+		// out of the game, which is over once every player is
+		p.Alive = false
+		gs.GameOver = true
+		for _, other := range gs.Players {
+			gs.GameOver = gs.GameOver && !other.Alive
+		}
+		return
+	}
+	p.Lives--
+	gs.Wave.died[p] = true
+
+	x, y := gs.spawnPoint(true)
+	p.spawnAt(gs, x, y)
+}
+
+func (p *Player) spawnAt(gs *GameState, x, y int) {
+	p.SetPos(float64(x), float64(y))
 	p.xSpeed = 0
 	p.flap = 0
-	p.buildSpawn(p, 0)
+	p.eggsInARow = 0
+	p.buildSpawn(gs, p, 0)
 	p.state = SPAWNING
 }
 
-func (p *Player) buildMount() *ebiten.Image {
+func (p *Player) buildMount(gs *GameState) *image.RGBA {
 	if p.flap == 1 {
 		p.Frame = 5
 	} else if p.flap == 2 || !p.walking {
 		p.Frame = 6
 	}
 
-	frame := p.Images[p.Frame]
-	composite := ebiten.NewImage(frame.Bounds().Dx(), frame.Bounds().Dy())
-
-	body := ebiten.NewImageFromImage(frame)
+	k := mountKey{body: p.Images[p.Frame], bodyColor: -1, riderColor: -1, left: !p.FacingRight}
 	if p.state == SPAWNING {
-		col := app.SpawnColors[rand.Intn(3)]
-		body = p.drawSolid(composite.Bounds(), col, body)
+		k.bodyColor = gs.cosmetic.Intn(len(app.SpawnColors))
 	}
-	op := ebiten.DrawImageOptions{}
-
-	// draw rider
 	if p.state != UNMOUNTED {
-		y := 0
-		if p.Frame == 4 {
-			y = 2
-		}
-		op.GeoM.Translate(float64(4), float64(y))
-		rider := ebiten.NewImageFromImage(p.rider)
+		k.rider = p.rider
+		k.riderY = playerRiderY(p.Frame)
 		if p.state == SPAWNING {
-			col := app.SpawnColors[rand.Intn(3)]
-			rider = p.drawSolid(rider.Bounds(), col, rider)
+			k.riderColor = gs.cosmetic.Intn(len(app.SpawnColors))
 		}
-		composite.DrawImage(rider, &op)
-		op.GeoM.Reset()
 	}
+	return gs.Sheet.Atlas.mount(k)
+}
 
-	// draw ostrich
-	composite.DrawImage(body, &op)
-	if !p.FacingRight {
-		return p.flipX(composite, op)
+// playerRiderY is how far down a player sits on their mount in each frame
+func playerRiderY(frame int) int {
+	if frame == 4 {
+		return 2
 	}
-	return composite
+	return 0
 }
@@ -0,0 +1,143 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"image"
+	"math"
+)
+
+// the open beak in the pterodactyl's first frame, when facing right
+var pterodactylMouth = image.Rect(20, 0, 30, 7)
+
+// Pterodactyl shows up when a wave drags on too long. It swoops across the arena in straight
+// lines between lanes and kills anything it touches, unless it takes a lance in its open mouth.
+type Pterodactyl struct {
+	*Sprite
+	FacingRight bool
+	left        []*image.RGBA // the frames facing left
+	targetY     float64
+	arrived     Tick
+	lastSwoop   Tick
+}
+
+func MakePterodactyl(ss *Sheet, facingRight bool, x, y float64, now Tick) *Pterodactyl {
+	p := &Pterodactyl{
+		Sprite:      MakeSprite(ss.Ptero),
+		FacingRight: facingRight,
+		left:        ss.pteroLeft,
+		targetY:     y,
+		arrived:     now,
+		lastSwoop:   now,
+	}
+	p.SetPos(x, y)
+	p.setFrame(ss, p.buildFrame(now))
+	return p
+}
+
+func (p *Pterodactyl) Update(gs *GameState) {
+	now := gs.Clock.Now()
+	if !now.Before(p.lastSwoop.Add(app.PteroSwoopTicks)) {
+		p.swoop(gs)
+	}
+
+	if p.FacingRight {
+		p.X += app.PteroSpeed
+	} else {
+		p.X -= app.PteroSpeed
+	}
+	if dy := p.targetY - p.Y; math.Abs(dy) > 1 {
+		p.Y += math.Copysign(1, dy)
+	}
+	before := p.X
+	if p.Wrap(gs) {
+		// turn back from the edge of an arena that doesn't wrap
+		p.FacingRight = p.X < gs.Width()/2
+	}
+	if p.X != before {
+		p.swoop(gs)
+	}
+
+	p.Collisions(gs, gs.CliffAsSprites(), func(c *Sprite) {
+		// glance off ledges rather than fly through them
+		if p.Y < c.centerY() {
+			p.Y -= 2
+		} else {
+			p.Y += 2
+		}
+		p.targetY = p.Y
+	})
+
+	p.setFrame(gs.Sheet, p.buildFrame(now))
+}
+
+// swoop picks a new lane to fly along, usually the one the nearest player is in
+func (p *Pterodactyl) swoop(gs *GameState) {
+	p.lastSwoop = gs.Clock.Now()
+	lane := gs.Lanes[gs.Rand.Intn(len(gs.Lanes))]
+	if gs.Rand.Float64() < app.PteroHunting {
+		target := gs.nearestPlayer(p.Sprite)
+		closest := math.MaxFloat64
+		for _, l := range gs.Lanes {
+			if dist := math.Abs(float64(l) - target.Y); dist < closest {
+				closest = dist
+				lane = l
+			}
+		}
+	}
+	p.targetY = float64(lane)
+}
+
+// mouthOpen is true for part of each cycle, the only time the pterodactyl can be killed
+func (p *Pterodactyl) mouthOpen(now Tick) bool {
+	return now.Sub(p.arrived)%app.PteroMouthCycle < app.PteroMouthTicks
+}
+
+func (p *Pterodactyl) buildFrame(now Tick) *image.RGBA {
+	frames := p.Images
+	if !p.FacingRight {
+		frames = p.left
+	}
+	if p.mouthOpen(now) {
+		return frames[0]
+	}
+	return frames[1+(now.Sub(p.arrived)/8)%2]
+}
+
+// mouth is where the open beak is on screen
+func (p *Pterodactyl) mouth() image.Rectangle {
+	r := p.rect()
+	m := pterodactylMouth
+	if !p.FacingRight {
+		m = image.Rect(p.Width-m.Max.X, m.Min.Y, p.Width-m.Min.X, m.Max.Y)
+	}
+	return m.Add(r.Min)
+}
+
+// lance is the point of the player's lance on screen
+func (p *Player) lance() image.Point {
+	r := p.rect()
+	if p.FacingRight {
+		return image.Pt(r.Max.X-1, r.Min.Y+4)
+	}
+	return image.Pt(r.Min.X, r.Min.Y+4)
+}
+
+func pterodactylCollision(gs *GameState, p *Player) {
+	now := gs.Clock.Now()
+	for _, ptero := range gs.Pteros {
+		if !ptero.Alive || !p.Collides(gs, ptero.Sprite) {
+			continue
+		}
+		headOn := p.FacingRight != ptero.FacingRight
+		mouth := ptero.mouth().Add(gs.nearRect(p.Sprite, ptero.Sprite).Min.Sub(ptero.rect().Min))
+		if headOn && ptero.mouthOpen(now) && p.lance().In(mouth.Inset(-2)) {
+			ptero.Alive = false
+			p.award(gs, app.PterodactylPoints)
+			gs.Wave.slain[p] = true
+			gs.PlaySound(app.HitSound)
+		} else {
+			p.state = UNMOUNTED
+			gs.PlaySound(app.HitSound)
+		}
+	}
+}
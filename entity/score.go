@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+)
+
+// award adds points to the player's score, granting an extra life each time the score
+// passes another multiple of ExtraLifePoints
+func (p *Player) award(gs *GameState, points int) {
+	before := p.Score / app.ExtraLifePoints
+	p.Score += points
+	if earned := p.Score/app.ExtraLifePoints - before; earned > 0 {
+		p.Lives += earned
+		gs.PlaySound(app.OneUpSound)
+	}
+}
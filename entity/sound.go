@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+)
+
+type SoundAction int
+
+const (
+	PlaySound     SoundAction = iota
+	StopSound     SoundAction = iota
+	StopAllSounds SoundAction = iota
+)
+
+// SoundEvent is a request from the simulation for the front end to start or stop a sound
+type SoundEvent struct {
+	Sound  app.Sound
+	Action SoundAction
+}
+
+func (gs *GameState) PlaySound(s app.Sound) {
+	gs.sounds = append(gs.sounds, SoundEvent{Sound: s, Action: PlaySound})
+}
+
+func (gs *GameState) StopSound(s app.Sound) {
+	gs.sounds = append(gs.sounds, SoundEvent{Sound: s, Action: StopSound})
+}
+
+func (gs *GameState) StopSounds() {
+	gs.sounds = append(gs.sounds, SoundEvent{Action: StopAllSounds})
+}
+
+// DrainSounds returns the sound events queued since it was last called, in order. They're
+// only good until it's called again, when their room gets reused.
+func (gs *GameState) DrainSounds() []SoundEvent {
+	result := gs.sounds
+	gs.sounds = gs.drained[:0]
+	gs.drained = result
+	return result
+}
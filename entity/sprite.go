@@ -4,14 +4,11 @@ import (
 	"bytes"
 	"github.com/depsypher/gojoust/app"
 	"github.com/depsypher/gojoust/assets/images"
-	"github.com/hajimehoshi/ebiten/v2"
 	"image"
-	_ "image"
 	"image/color"
 	"image/draw"
 	_ "image/png"
 	"math"
-	"time"
 )
 
 type Recter interface {
@@ -23,21 +20,23 @@ type Collider interface {
 }
 
 type Mount interface {
-	buildMount() *ebiten.Image
+	buildMount(gs *GameState) *image.RGBA
 }
 
 type Sprite struct {
-	Images []*ebiten.Image
-	image  *ebiten.Image
-	Frame  int
-	Width  int
-	Height int
-	X      float64
-	Y      float64
-	Vx     float64
-	Vy     float64
-	Alive  bool
-	center bool
+	Images  []*image.RGBA
+	image   *image.RGBA
+	version int // how many times image has been drawn on in place
+	mask    *Mask
+	Frame   int
+	Width   int
+	Height  int
+	X       float64
+	Y       float64
+	Vx      float64
+	Vy      float64
+	Alive   bool
+	center  bool
 }
 
 type MountSprite struct {
@@ -47,10 +46,10 @@ type MountSprite struct {
 	spawn       int
 	walking     bool
 	FacingRight bool
-	lastFlap    time.Time
+	lastFlap    Tick
 }
 
-func MakeSprite(images []*ebiten.Image, pos ...float64) *Sprite {
+func MakeSprite(images []*image.RGBA, pos ...float64) *Sprite {
 	position := []float64{0, 0}
 	if len(pos) == 2 {
 		position = pos
@@ -66,7 +65,7 @@ func MakeSprite(images []*ebiten.Image, pos ...float64) *Sprite {
 	}
 }
 
-func MakeMountSprite(images []*ebiten.Image, pos ...float64) *MountSprite {
+func MakeMountSprite(images []*image.RGBA, pos ...float64) *MountSprite {
 	position := []float64{0, 0}
 	if len(pos) == 2 {
 		position = pos
@@ -75,45 +74,56 @@ func MakeMountSprite(images []*ebiten.Image, pos ...float64) *MountSprite {
 		Sprite:      MakeSprite(images, position[0], position[1]),
 		flap:        0,
 		FacingRight: true,
-		lastFlap:    time.Time{},
 	}
 }
 
-func (p *MountSprite) buildSpawn(mount Mount, index int) {
-	p.Frame = 3
+// spawnFrame is the frame a mount shows while it rises out of a spawn pad
+const spawnFrame = 3
+
+func (p *MountSprite) buildSpawn(gs *GameState, mount Mount, index int) {
+	p.Frame = spawnFrame
 	p.walking = true
-	m := mount.buildMount()
-	if p.image == nil {
-		p.image = ebiten.NewImage(m.Bounds().Dx(), m.Bounds().Dy())
-	} else {
-		p.image.Clear()
-	}
-	op := ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(0), float64(m.Bounds().Dy()-index))
-	p.image.DrawImage(m, &op)
-}
-
-func (p *MountSprite) doFlap() {
-	if time.Now().After(p.lastFlap.Add(time.Millisecond * time.Duration(200))) {
-		closestDist := math.MaxFloat64
-		closestLane := 0
-		for _, lane := range app.Lanes {
-			dist := app.WrappedDistance(int(p.X), int(p.Y), int(p.X), lane)
-			if dist < closestDist {
-				closestDist = dist
-				closestLane = lane
-			}
-		}
+	p.setFrame(gs.Sheet, gs.Sheet.Atlas.risingFrom(mount.buildMount(gs), index))
+}
 
-		if closestLane < int(p.Y) {
-			p.Frame = 5
-			p.walking = false
-			p.Vy = -0.3 //-= 0.6
-			p.lastFlap = time.Now()
-		} else if !p.walking {
-			p.Frame = 6
+func (p *MountSprite) mountSprite() *MountSprite {
+	return p
+}
+
+// doFlap keeps a riderless mount flying along the closest lane
+func (p *MountSprite) doFlap(gs *GameState, flapTicks int) {
+	closestDist := math.MaxFloat64
+	closestLane := 0
+	for _, lane := range gs.Lanes {
+		dist := math.Abs(p.Y - float64(lane))
+		if dist < closestDist {
+			closestDist = dist
+			closestLane = lane
 		}
 	}
+	p.flapToward(gs, float64(closestLane), flapTicks)
+}
+
+// flapToward flaps whenever the mount has sunk below the height it's after, and glides
+// otherwise
+func (p *MountSprite) flapToward(gs *GameState, y float64, flapTicks int) {
+	if !gs.Clock.Now().Before(p.lastFlap.Add(flapTicks)) {
+		p.flapWings(gs, y < p.Y)
+	}
+}
+
+// flapWings gives the mount a lift when asked to, and spreads its wings to glide once the
+// flap is done
+func (p *MountSprite) flapWings(gs *GameState, flap bool) {
+	now := gs.Clock.Now()
+	if flap {
+		p.Frame = 5
+		p.walking = false
+		p.Vy = -0.3 //-= 0.6
+		p.lastFlap = now
+	} else if !p.walking && !now.Before(p.lastFlap.Add(app.GlideTicks)) {
+		p.Frame = 6
+	}
 }
 
 func (p *MountSprite) velocity() {
@@ -143,10 +153,32 @@ func (p *MountSprite) velocity() {
 	}
 }
 
-func (s *Sprite) drawSolid(bounds image.Rectangle, color color.Color, mask image.Image) *ebiten.Image {
-	img := ebiten.NewImage(bounds.Dx(), bounds.Dy())
-	img.Fill(color)
-	draw.DrawMask(img, bounds, img, image.Point{}, mask, image.Point{}, draw.Src)
+// Image is the current frame to draw for the sprite
+func (s *Sprite) Image() *image.RGBA {
+	return s.image
+}
+
+// Version changes whenever the sprite's image is drawn on in place, so a copy of it can tell
+// when it's out of date even though it's still the same image
+func (s *Sprite) Version() int {
+	return s.version
+}
+
+func (s *Sprite) setImage(img *image.RGBA) {
+	s.image = img
+	s.mask = MaskOf(img)
+}
+
+// setFrame shows one of the pictures from the sheet or its atlas, whose mask was worked out
+// when the sheet loaded
+func (s *Sprite) setFrame(ss *Sheet, img *image.RGBA) {
+	s.image = img
+	s.mask = ss.maskOf(img)
+}
+
+func drawSolid(bounds image.Rectangle, color color.Color, mask image.Image) *image.RGBA {
+	img := image.NewRGBA(bounds)
+	draw.DrawMask(img, bounds, image.NewUniform(color), image.Point{}, mask, mask.Bounds().Min, draw.Src)
 	return img
 }
 
@@ -159,6 +191,14 @@ func (s *Sprite) rect() image.Rectangle {
 	return image.Rect(int(s.X), int(s.Y), int(s.X)+s.Width, int(s.Y)+s.Height)
 }
 
+// TopLeft is the screen position to draw the sprite's image at
+func (s *Sprite) TopLeft() (float64, float64) {
+	if s.center {
+		return s.X - float64(s.Width)/2, s.Y - float64(s.Height)/2
+	}
+	return s.X, s.Y
+}
+
 func (s *Sprite) SetPos(x float64, y float64) {
 	s.X = x
 	s.Y = y
@@ -182,127 +222,174 @@ func (s *Sprite) Fall() {
 	s.Y += s.Vy //* app.TimeStepSec
 }
 
-func (s *Sprite) Wrap() {
-	w := float64(s.Width / 2)
-	if s.X > app.ScreenWidth+w {
-		s.X = -w
-	} else if s.X < -w {
-		s.X = app.ScreenWidth + w
+// Wrap brings the sprite back on the other side once its middle crosses one side of the
+// world, from then on drawn straddling the seam. In a world that doesn't wrap it stops at the
+// edge instead, and reports that it hit the wall.
+func (s *Sprite) Wrap(gs *GameState) bool {
+	width := gs.Width()
+	if !gs.Wraps() {
+		w := float64(s.Width / 2)
+		x := math.Max(w, math.Min(s.X, width-w))
+		hit := x != s.X
+		s.X = x
+		return hit
 	}
+	if s.X >= width {
+		s.X -= width
+	} else if s.X < 0 {
+		s.X += width
+	}
+	return false
 }
 
-func (s *Sprite) flipX(image *ebiten.Image, op ebiten.DrawImageOptions) *ebiten.Image {
-	width := image.Bounds().Dx()
-	op.GeoM.Reset()
-	op.GeoM.Scale(-1, 1)
-	op.GeoM.Translate(float64(width), 0)
-	left := ebiten.NewImage(width, image.Bounds().Dy())
-	left.DrawImage(image, &op)
+func flipX(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	left := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			left.SetRGBA(b.Max.X-1-(x-b.Min.X), y, img.RGBAAt(x, y))
+		}
+	}
 	return left
 }
 
-func (s *Sprite) DrawSprite(screen *ebiten.Image) {
-	op := &ebiten.DrawImageOptions{}
-	if s.center {
-		op.GeoM.Translate(-float64(s.Width)/2, -float64(s.Height)/2)
-	}
-	op.GeoM.Translate(s.X, s.Y)
-	if s.image != nil {
-		screen.DrawImage(s.image, op)
-	}
-}
-
-func (s *Sprite) Collides(c *Sprite) bool {
-	intersect := s.rect().Intersect(c.rect())
-	result := intersect != image.Rectangle{}
-	if result {
-		// check pixels
-		hitBoxMinX := intersect.Min.X
-		hitBoxMinY := intersect.Min.Y
-		hitBoxMaxX := intersect.Max.X
-		hitBoxMaxY := intersect.Max.Y
-
-		for y := hitBoxMinY; y < hitBoxMaxY; y++ {
-			for x := hitBoxMinX; x < hitBoxMaxX; x++ {
-				if s.image == nil || c.image == nil {
-					return true
-				}
-				alpha1 := s.image.RGBA64At(x-s.rect().Min.X, y-s.rect().Min.Y).A
-				if alpha1 != 0 {
-					alpha2 := c.image.RGBA64At(x-c.rect().Min.X, y-c.rect().Min.Y).A
-					//fmt.Println(x-c.rect().Min.X, y-c.rect().Min.Y, alpha1, alpha2)
-					if alpha2 != 0 {
-						return true
-					}
-				}
-			}
-		}
+// Collides is whether any pixels of the two sprites overlap, testing against whichever copy
+// of c is nearest when the world wraps so sprites meet across the seam
+func (s *Sprite) Collides(gs *GameState, c *Sprite) bool {
+	sr := s.rect()
+	cr := gs.nearRect(s, c)
+	intersect := sr.Intersect(cr)
+	if intersect.Empty() {
+		return false
 	}
-	return false
+	if s.mask == nil || c.mask == nil {
+		return true
+	}
+	return s.mask.Overlaps(sr.Min, c.mask, cr.Min, intersect)
 }
 
 type doOnCollide func(c *Sprite)
 
-func (s *Sprite) Collisions(group []*Sprite, onCollide doOnCollide) []*Sprite {
-	var result []*Sprite
+func (s *Sprite) Collisions(gs *GameState, group []*Sprite, onCollide doOnCollide) {
 	for _, c := range group {
-		if s != c && s.Collides(c) {
+		if s != c && s.Collides(gs, c) {
 			onCollide(c)
-			result = append(result, c)
 		}
 	}
-	return result
 }
 
 type Sheet struct {
-	P1Rider *ebiten.Image
-	Ostrich []*ebiten.Image
-	Buzzard []*ebiten.Image
-	Bounder *ebiten.Image
-	C1      *ebiten.Image
-	C2      *ebiten.Image
-	C3      *ebiten.Image
-	C4      *ebiten.Image
-	C5      *ebiten.Image
-	C6      *ebiten.Image
-	C7      *ebiten.Image
-	C8      *ebiten.Image
+	P1Rider *image.RGBA
+	P2Rider *image.RGBA
+	Ostrich []*image.RGBA
+	Stork   []*image.RGBA
+	Buzzard []*image.RGBA
+	Riders  []*image.RGBA // enemy riders indexed by EnemyClass
+	Egg     []*image.RGBA
+	Knights []*image.RGBA
+	Life    *image.RGBA
+	Ptero   []*image.RGBA
+	Troll   []*image.RGBA
+	Font    map[rune]*image.RGBA
+	Atlas   *Atlas
+	src     image.Image
+
+	pteroLeft []*image.RGBA         // Ptero facing left
+	masks     map[*image.RGBA]*Mask // of everything sprites show from one tick to the next
+}
+
+// Region copies a rectangle out of the sheet so its bounds start at the origin
+func (s *Sheet) Region(r Region) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.W, r.H))
+	draw.Draw(img, img.Bounds(), s.src, image.Pt(r.X, r.Y), draw.Src)
+	return img
+}
+
+// maskOf is the mask of img, worked out ahead of time for anything from the sheet
+func (s *Sheet) maskOf(img *image.RGBA) *Mask {
+	if m, ok := s.masks[img]; ok {
+		return m
+	}
+	return MaskOf(img)
+}
+
+// sheetBounds is the size of the sprite sheet, without decoding the whole thing
+func sheetBounds() image.Rectangle {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(images.Spritesheet_png))
+	if err != nil {
+		return image.Rectangle{}
+	}
+	return image.Rect(0, 0, cfg.Width, cfg.Height)
 }
 
 func LoadSpriteSheet() (*Sheet, error) {
-	img, _, err := image.Decode(bytes.NewReader(images.Spritesheet_png))
+	sheet, _, err := image.Decode(bytes.NewReader(images.Spritesheet_png))
 	if err != nil {
 		return nil, err
 	}
 
-	sheet := ebiten.NewImageFromImage(img)
-
-	spriteAt := func(x, y, w, h int) *ebiten.Image {
-		return sheet.SubImage(image.Rect(x, y, x+w, y+h)).(*ebiten.Image)
+	s := &Sheet{src: sheet}
+	spriteAt := func(x, y, w, h int) *image.RGBA {
+		return s.Region(Region{X: x, Y: y, W: w, H: h})
 	}
 
-	spriteFramesAt := func(x, y, w, h, gap, count int) []*ebiten.Image {
-		var result []*ebiten.Image
+	spriteFramesAt := func(x, y, w, h, gap, count int) []*image.RGBA {
+		var result []*image.RGBA
 		for i := 0; i < count; i++ {
 			var frameX = i*w + i*gap
-			result = append(result, sheet.SubImage(image.Rect(x+frameX, y, x+frameX+w, y+h)).(*ebiten.Image))
+			result = append(result, spriteAt(x+frameX, y, w, h))
 		}
 		return result
 	}
 
-	s := &Sheet{}
 	s.P1Rider = spriteAt(58, 79, 12, 7)
+	s.P2Rider = spriteAt(73, 79, 12, 7)
 	s.Ostrich = spriteFramesAt(348, 19, 16, 20, 5, 8)
+	for _, x := range []int{192, 215, 236, 259, 282, 303, 326} {
+		s.Stork = append(s.Stork, spriteAt(x, 19, 16, 20))
+	}
 	s.Buzzard = spriteFramesAt(191, 44, 20, 20, 3, 7)
-	s.Bounder = spriteAt(58, 69, 12, 7)
-	s.C1 = spriteAt(0, 19, 190, 30)
-	s.C2 = spriteAt(385, 0, 64, 8)  // 315, 420    # mid-bottom
-	s.C3 = spriteAt(82, 0, 88, 9)   // 250, 201    # mid-top
-	s.C4 = spriteAt(0, 9, 50, 7)    // -60, 168    # top-left
-	s.C5 = spriteAt(0, 0, 64, 7)    // 759, 168    # top-right
-	s.C6 = spriteAt(173, 0, 80, 8)  // -50, 354    # bottom-left
-	s.C7 = spriteAt(319, 0, 63, 7)  // 770, 354    # bottom-right
-	s.C8 = spriteAt(254, 0, 58, 11) // 606, 330    # mid-right
+	s.Riders = []*image.RGBA{
+		spriteAt(58, 69, 12, 7), // bounder
+		spriteAt(73, 69, 12, 7), // hunter
+		spriteAt(88, 69, 12, 7), // shadow lord
+	}
+	s.Egg = []*image.RGBA{
+		spriteAt(139, 65, 10, 12), // upright
+		spriteAt(151, 65, 10, 12), // wobble left
+		spriteAt(163, 65, 10, 12), // wobble right
+		spriteAt(176, 65, 10, 12), // cracked
+	}
+	s.Knights = []*image.RGBA{
+		spriteAt(49, 53, 10, 12), // bounder
+		spriteAt(72, 53, 10, 12), // hunter
+		spriteAt(94, 53, 10, 12), // shadow lord
+	}
+	s.Ptero = spriteFramesAt(357, 48, 30, 12, 3, 3)
+	s.Troll = []*image.RGBA{
+		spriteAt(281, 69, 14, 18), // reaching
+		spriteAt(297, 69, 14, 18), // fingers spread
+		spriteAt(316, 69, 14, 18), // clutching
+	}
+	s.Life = spriteAt(90, 79, 5, 7)
+	s.Font = make(map[rune]*image.RGBA)
+	for i, r := range []rune("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ←=-?!()',./&:") {
+		s.Font[r] = spriteAt(1+i*11, 93, 7, 7)
+	}
+	for _, frame := range s.Ptero {
+		s.pteroLeft = append(s.pteroLeft, flipX(frame))
+	}
+	s.Atlas = s.buildAtlas()
+
+	s.masks = make(map[*image.RGBA]*Mask)
+	for _, frames := range [][]*image.RGBA{s.Egg, s.Knights, s.Ptero, s.pteroLeft, s.Troll} {
+		for _, frame := range frames {
+			s.masks[frame] = MaskOf(frame)
+		}
+	}
+	for img := range s.Atlas.held {
+		s.masks[img] = MaskOf(img)
+	}
 
 	return s, nil
 }
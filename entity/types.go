@@ -2,41 +2,73 @@ package entity
 
 import (
 	"github.com/depsypher/gojoust/app"
-	"github.com/depsypher/gojoust/assets/audio"
-	"time"
+	"math/rand"
 )
 
 type GameObject interface {
 	Update(g *GameState)
 }
 
+// Mode is whether the players are out to beat each other or working together
+type Mode int
+
+const (
+	Competitive Mode = iota // players joust each other like they do the enemies
+	Coop        Mode = iota // players just bounce off each other
+)
+
 type GameState struct {
-	Buzzards  []*Buzzard
-	Cliffs    []*Cliff
-	Player    *Player
-	Keys      map[app.Control]bool
-	GodMode   bool
-	SoundOn   bool
-	CrtOn     bool
-	Pause     bool
-	Debug     string
-	Sounds    audio.GameSounds
-	WaveStart time.Time
-	NextSpawn time.Time
+	Buzzards []*Buzzard
+	Eggs     []*Egg
+	Pteros   []*Pterodactyl
+	Cliffs   []*Cliff
+	Troll    *Troll
+	Players  []*Player
+	Keys     map[app.Control]bool // buttons held this tick, read by KeyController and the scroll toggle
+	GameOver bool
+	Debug    string
+	Sheet    *Sheet
+	Level    *Level
+	Seed     int64
+	Mode     Mode
+	Rand     *rand.Rand
+	cosmetic *rand.Rand // for what only changes how things look, so it can't sway the game
+	Clock    Clock
+	Wave     *Wave
+	Lanes    []int // the level's lanes that still have ledges under them this wave
+	sounds   []SoundEvent
+	drained  []SoundEvent
+
+	// Controllers drive each player slot, and Intents are what they wanted this tick
+	Controllers []Controller
+	Intents     []Intent
+
+	Scrolling  bool    // the level is laid out across a world wider than the screen
+	Camera     float64 // left edge of the part of the world on screen
+	scrollHeld bool
+
+	cliffs   []*Sprite // room for CliffAsSprites
+	buzzards []*Sprite // room for BuzzardsAsSprites
+	pads     []*Cliff  // room for spawnPads
 }
 
+// CliffAsSprites is the ledges still standing. The slice is reused from one call to the
+// next, so it's only good until the ledges are asked for again.
 func (gs *GameState) CliffAsSprites() []*Sprite {
-	r := make([]*Sprite, len(gs.Cliffs))
-	for i := range gs.Cliffs {
-		r[i] = gs.Cliffs[i].Sprite
+	gs.cliffs = gs.cliffs[:0]
+	for _, c := range gs.Cliffs {
+		if c.state != GONE {
+			gs.cliffs = append(gs.cliffs, c.Sprite)
+		}
 	}
-	return r
+	return gs.cliffs
 }
 
+// BuzzardsAsSprites is the enemies' mounts, in a slice reused like CliffAsSprites'
 func (gs *GameState) BuzzardsAsSprites() []*Sprite {
-	r := make([]*Sprite, len(gs.Buzzards))
-	for i := range gs.Buzzards {
-		r[i] = gs.Buzzards[i].MountSprite.Sprite
+	gs.buzzards = gs.buzzards[:0]
+	for _, b := range gs.Buzzards {
+		gs.buzzards = append(gs.buzzards, b.MountSprite.Sprite)
 	}
-	return r
+	return gs.buzzards
 }
@@ -0,0 +1,210 @@
+package entity
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"strconv"
+)
+
+type WaveKind int
+
+const (
+	NormalWave      WaveKind = iota
+	SurvivalWave    WaveKind = iota
+	GladiatorWave   WaveKind = iota
+	EggWave         WaveKind = iota
+	PterodactylWave WaveKind = iota
+	TeamWave        WaveKind = iota
+)
+
+// Wave is one round of enemies. It starts with a banner, then spawns its enemies one at a
+// time and ends once every enemy and egg has been cleared from the arena.
+type Wave struct {
+	Number    int
+	Kind      WaveKind
+	Enemies   []EnemyClass
+	Tactics   []Tactics // how each class of enemy fights this wave, indexed by EnemyClass
+	Start     Tick
+	nextSpawn Tick
+	nextPtero Tick
+	spawned   int
+	died      map[*Player]bool
+	slain     map[*Player]bool // players who killed a pterodactyl
+	betrayed  bool             // a player unhorsed another
+	gladiator bool             // the gladiator bonus has been won
+	hatched   bool             // an egg hatched
+}
+
+// MakeWave works out the kind of wave and the mix of enemies for the given wave number.
+// Special waves come around every five waves like the arcade: survival on 2, 7, 12...,
+// egg waves on 5, 10, 15... and pterodactyls on 8, 13, 18... With more than one player every
+// other survival wave is a team wave instead, and 4, 9, 14... are gladiator waves unless
+// they're playing co-op, since it takes two to fight for the gladiator bonus.
+func MakeWave(number int, players int, mode Mode, start Tick) *Wave {
+	w := &Wave{
+		Number:  number,
+		Start:   start,
+		died:    make(map[*Player]bool),
+		slain:   make(map[*Player]bool),
+		Tactics: tacticsFor(number),
+	}
+	switch {
+	case number%10 == 7 && players > 1:
+		w.Kind = TeamWave
+	case number%5 == 2:
+		w.Kind = SurvivalWave
+	case number%5 == 4 && players > 1 && mode == Competitive:
+		w.Kind = GladiatorWave
+	case number%5 == 0:
+		w.Kind = EggWave
+	case number%5 == 3 && number > 3:
+		w.Kind = PterodactylWave
+	}
+
+	// a pterodactyl comes after anyone who takes too long, or straight away on its own wave
+	w.nextPtero = start.Add(app.WaveDelayTicks + app.PteroArrivalTicks)
+	if w.Kind == PterodactylWave {
+		w.nextPtero = start.Add(app.WaveDelayTicks)
+	}
+
+	total := min(3+number/2, app.MaxEnemies)
+	lords := max(0, min((number-5)/2, total))
+	hunters := max(0, min((number-1)/2, total-lords))
+	for i := 0; i < total; i++ {
+		switch {
+		case i < total-hunters-lords:
+			w.Enemies = append(w.Enemies, BOUNDER)
+		case i < total-lords:
+			w.Enemies = append(w.Enemies, HUNTER)
+		default:
+			w.Enemies = append(w.Enemies, SHADOW_LORD)
+		}
+	}
+	return w
+}
+
+// Banner is the text shown while the wave is getting started
+func (w *Wave) Banner() []string {
+	var result []string
+	if w.Number == 1 {
+		result = append(result, "PREPARE TO JOUST")
+	}
+	result = append(result, "WAVE "+strconv.Itoa(w.Number))
+	switch w.Kind {
+	case SurvivalWave:
+		result = append(result, "SURVIVAL WAVE")
+	case GladiatorWave:
+		result = append(result, "GLADIATOR WAVE")
+	case EggWave:
+		result = append(result, "EGG WAVE")
+	case PterodactylWave:
+		result = append(result, "PTERODACTYL WAVE")
+	case TeamWave:
+		result = append(result, "TEAM WAVE")
+	}
+	return result
+}
+
+func (w *Wave) ShowBanner(now Tick) bool {
+	return now.Before(w.Start.Add(app.WaveDelayTicks))
+}
+
+func (gs *GameState) startWave(number int) {
+	gs.Wave = MakeWave(number, len(gs.Players), gs.Mode, gs.Clock.Now())
+	for _, p := range gs.Players {
+		p.eggsInARow = 0
+	}
+	gs.Pteros = nil
+	gs.layoutCliffs(number)
+	gs.layoutLanes()
+	if gs.Wave.Kind == EggWave {
+		gs.placeEggs()
+	}
+}
+
+// placeEggs lays an egg for each enemy of an egg wave out along the ledges, where they sit
+// until they hatch
+func (gs *GameState) placeEggs() {
+	w := gs.Wave
+	var ledges []*Cliff
+	for _, c := range gs.Cliffs {
+		if c.Solid() {
+			ledges = append(ledges, c)
+		}
+	}
+	offsets := []float64{0, -2, 2, -4, 4}
+	for i, class := range w.Enemies {
+		c := ledges[i%len(ledges)]
+		slot := i / len(ledges)
+		e := MakeEgg(gs.Sheet, class, 0, 0, 0, 0)
+		x := c.centerX() + offsets[slot%len(offsets)]*float64(e.Width)
+		e.SetPos(x, c.Y-float64(e.Height)/2)
+		e.state = SETTLED
+		e.settled = gs.Clock.Now()
+		gs.Eggs = append(gs.Eggs, e)
+	}
+	w.spawned = len(w.Enemies)
+}
+
+func (gs *GameState) updateWave() {
+	w := gs.Wave
+	now := gs.Clock.Now()
+	if w.ShowBanner(now) || gs.GameOver {
+		return
+	}
+
+	if !now.Before(w.nextPtero) {
+		lane := gs.Lanes[gs.Rand.Intn(len(gs.Lanes))]
+		right := gs.Rand.Float32() < 0.5
+		x := gs.edge(right, gs.Sheet.Ptero[0].Bounds().Dx())
+		gs.Pteros = append(gs.Pteros, MakePterodactyl(gs.Sheet, right, x, float64(lane), now))
+		gs.PlaySound(app.PteroSound)
+		w.nextPtero = now.Add(app.PteroArrivalTicks)
+	}
+
+	if w.spawned < len(w.Enemies) {
+		if !now.Before(w.nextSpawn) {
+			buzz := MakeBuzzard(gs.Sheet, w.Enemies[w.spawned])
+			x, y := gs.spawnPoint(false)
+			buzz.SetPos(float64(x), float64(y))
+			if gs.Rand.Float32() < 0.5 {
+				buzz.FacingRight = false
+			}
+			gs.Buzzards = append(gs.Buzzards, buzz)
+			w.spawned++
+			w.nextSpawn = now.Add(app.SpawnDelayTicks)
+		}
+		return
+	}
+
+	if gs.enemiesLeft() == 0 && len(gs.Eggs) == 0 {
+		for _, p := range gs.Players {
+			if !p.Alive {
+				continue
+			}
+			if w.Kind == SurvivalWave && !w.died[p] {
+				p.award(gs, app.SurvivalPoints)
+			}
+			if w.Kind == TeamWave && !w.betrayed {
+				p.award(gs, app.TeamPoints)
+			}
+			if w.Kind == EggWave && !w.hatched {
+				p.award(gs, app.EggWavePoints)
+			}
+			if w.Kind == PterodactylWave && w.slain[p] {
+				p.award(gs, app.PteroWavePoints)
+			}
+		}
+		gs.startWave(w.Number + 1)
+	}
+}
+
+// enemiesLeft counts the buzzards that still have a rider
+func (gs *GameState) enemiesLeft() int {
+	result := 0
+	for _, b := range gs.Buzzards {
+		if b.state != UNMOUNTED && b.state != DEAD {
+			result++
+		}
+	}
+	return result
+}
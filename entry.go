@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/depsypher/gojoust/render"
+	"github.com/hajimehoshi/ebiten/v2"
+	"log"
+	"time"
+	"unicode"
+)
+
+// enterScores has each player with a high score put their initials in, one after another,
+// then goes back to the title. Watching a replay doesn't earn anyone a place.
+func (g *Game) enterScores(players []*entity.Player) {
+	for i, p := range players {
+		if !g.watched && g.scores.Qualifies(p.Score, time.Now()) {
+			g.show(&entryScreen{player: p, rest: players[i+1:], initials: []rune{'A'}})
+			return
+		}
+	}
+	g.show(newTitle())
+}
+
+// where things go on the initials screen
+const (
+	entryTop      = 40
+	initialsY     = 100
+	initialsScale = 3
+	entryHintY    = 160
+)
+
+var (
+	initialsText = &render.TextOptions{Align: render.ALIGN_LEFT, Scale: initialsScale, Color: app.White}
+	pickingText  = &render.TextOptions{Align: render.ALIGN_LEFT, Scale: initialsScale}
+)
+
+// entryScreen is where a player with a high score puts in their initials, picking each
+// letter with their left and right controls and flapping to take it. Typing them works too.
+type entryScreen struct {
+	player   *entity.Player
+	rest     []*entity.Player // who's still to enter theirs
+	initials []rune           // picked so far, the last being the one being picked
+	typed    []rune
+	ticks    int
+}
+
+func (e *entryScreen) Update(g *Game) error {
+	e.ticks++
+	controls := app.PlayerControls[e.player.Number]
+	move, choose, back := menuInput()
+	// up goes forward through the alphabet like the arcade's joystick
+	move = -move
+	if justPressed(controls.Left) {
+		move--
+	}
+	if justPressed(controls.Right) {
+		move++
+	}
+	choose = choose || justPressed(controls.Flap)
+	picking := &e.initials[len(e.initials)-1]
+	*picking = 'A' + (*picking-'A'+rune(move)+26)%26
+
+	e.typed = ebiten.AppendInputChars(e.typed[:0])
+	for _, c := range e.typed {
+		if c = unicode.ToUpper(c); c >= 'A' && c <= 'Z' {
+			*picking = c
+			choose = true
+		}
+	}
+	switch {
+	case back && len(e.initials) > 1:
+		e.initials = e.initials[:len(e.initials)-1]
+	case choose && len(e.initials) < app.InitialsLength:
+		e.initials = append(e.initials, 'A')
+	case choose:
+		if err := g.scores.Add(string(e.initials), e.player, g.recording, time.Now()); err != nil {
+			log.Printf("failed to save high scores: %s", err)
+		}
+		g.enterScores(e.rest)
+	}
+	return nil
+}
+
+func (e *entryScreen) Draw(g *Game, dst *ebiten.Image) {
+	lines := fmt.Sprintf("player %d\n\ngreat jousting!\nyou scored %d\n\nenter your initials", e.player.Number+1, e.player.Score)
+	g.renderer.DrawText(dst, lines, app.ScreenWidth/2, entryTop, centered)
+
+	width := render.TextWidth(string(make([]rune, app.InitialsLength))) * initialsScale
+	x := (app.ScreenWidth - width) / 2
+	for i, c := range e.initials {
+		op := initialsText
+		if i == len(e.initials)-1 {
+			if !blink(e.ticks) {
+				continue
+			}
+			op = pickingText
+		}
+		g.renderer.DrawText(dst, string(c), x+render.TextWidth(string(e.initials[:i]))*initialsScale, initialsY, op)
+	}
+	g.renderer.DrawText(dst, "left and right change the letter\nflap takes it", app.ScreenWidth/2, entryHintY, hintText)
+}
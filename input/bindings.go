@@ -0,0 +1,197 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/storage"
+	"github.com/hajimehoshi/ebiten/v2"
+	"io/fs"
+	"slices"
+)
+
+// bindingsFile is where the bindings are saved between runs
+const bindingsFile = "bindings.json"
+
+// ButtonNames are what the buttons of the standard gamepad layout are called in the options
+// and the saved bindings
+var ButtonNames = []string{
+	ebiten.StandardGamepadButtonRightBottom:      "a",
+	ebiten.StandardGamepadButtonRightRight:       "b",
+	ebiten.StandardGamepadButtonRightLeft:        "x",
+	ebiten.StandardGamepadButtonRightTop:         "y",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "lb",
+	ebiten.StandardGamepadButtonFrontTopRight:    "rb",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "lt",
+	ebiten.StandardGamepadButtonFrontBottomRight: "rt",
+	ebiten.StandardGamepadButtonCenterLeft:       "back",
+	ebiten.StandardGamepadButtonCenterRight:      "start",
+	ebiten.StandardGamepadButtonLeftStick:        "ls",
+	ebiten.StandardGamepadButtonRightStick:       "rs",
+	ebiten.StandardGamepadButtonLeftTop:          "up",
+	ebiten.StandardGamepadButtonLeftBottom:       "down",
+	ebiten.StandardGamepadButtonLeftLeft:         "left",
+	ebiten.StandardGamepadButtonLeftRight:        "right",
+	ebiten.StandardGamepadButtonCenterCenter:     "home",
+}
+
+// Bindings are the key and the gamepad button for each control
+type Bindings struct {
+	Keys    map[app.Control]ebiten.Key
+	Buttons map[app.Control]ebiten.StandardGamepadButton
+}
+
+func DefaultBindings() *Bindings {
+	return &Bindings{
+		Keys: map[app.Control]ebiten.Key{
+			app.LeftButton:    ebiten.KeyLeft,
+			app.RightButton:   ebiten.KeyRight,
+			app.FlapButton:    ebiten.KeySpace,
+			app.GodModeButton: ebiten.KeyG,
+			app.PauseButton:   ebiten.KeyP,
+			app.SoundButton:   ebiten.KeyS,
+			app.CrtButton:     ebiten.KeyC,
+			app.P2LeftButton:  ebiten.KeyA,
+			app.P2RightButton: ebiten.KeyD,
+			app.P2FlapButton:  ebiten.KeyW,
+			app.ScrollButton:  ebiten.KeyM,
+			app.OptionsButton: ebiten.KeyO,
+		},
+		Buttons: map[app.Control]ebiten.StandardGamepadButton{
+			app.LeftButton:    ebiten.StandardGamepadButtonLeftLeft,
+			app.RightButton:   ebiten.StandardGamepadButtonLeftRight,
+			app.FlapButton:    ebiten.StandardGamepadButtonRightBottom,
+			app.GodModeButton: ebiten.StandardGamepadButtonCenterLeft,
+			app.PauseButton:   ebiten.StandardGamepadButtonCenterRight,
+			app.SoundButton:   ebiten.StandardGamepadButtonFrontTopLeft,
+			app.CrtButton:     ebiten.StandardGamepadButtonFrontTopRight,
+			app.P2LeftButton:  ebiten.StandardGamepadButtonLeftLeft,
+			app.P2RightButton: ebiten.StandardGamepadButtonLeftRight,
+			app.P2FlapButton:  ebiten.StandardGamepadButtonRightBottom,
+			app.ScrollButton:  ebiten.StandardGamepadButtonRightTop,
+			app.OptionsButton: ebiten.StandardGamepadButtonFrontBottomRight,
+		},
+	}
+}
+
+// LoadBindings loads the bindings saved last time, on top of the defaults so controls added
+// since then still have one. If the saved bindings can't be read the defaults come back
+// along with why.
+func LoadBindings() (*Bindings, error) {
+	b := DefaultBindings()
+	data, err := storage.Load(bindingsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	var saved savedBindings
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return DefaultBindings(), err
+	}
+	for name, key := range saved.Keys {
+		c, err := controlNamed(name)
+		if err != nil {
+			return DefaultBindings(), err
+		}
+		b.Keys[c] = key
+	}
+	for name, buttonName := range saved.Buttons {
+		c, err := controlNamed(name)
+		if err != nil {
+			return DefaultBindings(), err
+		}
+		button := slices.Index(ButtonNames, buttonName)
+		if button < 0 {
+			return DefaultBindings(), fmt.Errorf("no gamepad button called %q", buttonName)
+		}
+		b.Buttons[c] = ebiten.StandardGamepadButton(button)
+	}
+	return b, nil
+}
+
+// Save keeps the bindings for the next time the game runs
+func (b *Bindings) Save() error {
+	saved := savedBindings{Keys: map[string]ebiten.Key{}, Buttons: map[string]string{}}
+	for c, key := range b.Keys {
+		saved.Keys[c.String()] = key
+	}
+	for c, button := range b.Buttons {
+		saved.Buttons[c.String()] = ButtonNames[button]
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.Save(bindingsFile, data)
+}
+
+// BindKey binds the key to the control. If another control already had the key they swap,
+// and that control is returned.
+func (b *Bindings) BindKey(c app.Control, key ebiten.Key) (app.Control, bool) {
+	for other, k := range b.Keys {
+		if other != c && k == key {
+			b.Keys[other] = b.Keys[c]
+			b.Keys[c] = key
+			return other, true
+		}
+	}
+	b.Keys[c] = key
+	return c, false
+}
+
+// BindButton binds the gamepad button to the control. If another control that's read from
+// the same gamepad already had the button they swap, and that control is returned.
+func (b *Bindings) BindButton(c app.Control, button ebiten.StandardGamepadButton) (app.Control, bool) {
+	for other, bb := range b.Buttons {
+		if other != c && bb == button && sharePad(c, other) {
+			b.Buttons[other] = b.Buttons[c]
+			b.Buttons[c] = button
+			return other, true
+		}
+	}
+	b.Buttons[c] = button
+	return c, false
+}
+
+// Keyboard drives a player from the keys bound to their controls
+func (b *Bindings) Keyboard(c app.Controls) Keyboard {
+	return Keyboard{Left: b.Keys[c.Left], Right: b.Keys[c.Right], Flap: b.Keys[c.Flap]}
+}
+
+// Gamepad is the layout of a player's gamepad from the buttons bound to their controls
+func (b *Bindings) Gamepad(c app.Controls) Gamepad {
+	return Gamepad{ID: noGamepad, Left: b.Buttons[c.Left], Right: b.Buttons[c.Right], Flap: b.Buttons[c.Flap]}
+}
+
+// sharePad is whether two controls can be pressed on the same gamepad. Each player steers
+// from their own gamepad, while the rest of the controls are read from all of them.
+func sharePad(a, b app.Control) bool {
+	pa, pb := playerOf(a), playerOf(b)
+	return pa < 0 || pb < 0 || pa == pb
+}
+
+// playerOf is the number of the player who steers with the control, or -1 for the rest
+func playerOf(c app.Control) int {
+	for n, pc := range app.PlayerControls {
+		if c == pc.Left || c == pc.Right || c == pc.Flap {
+			return n
+		}
+	}
+	return -1
+}
+
+func controlNamed(name string) (app.Control, error) {
+	c := slices.Index(app.ControlNames, name)
+	if c < 0 {
+		return 0, fmt.Errorf("no control called %q", name)
+	}
+	return app.Control(c), nil
+}
+
+type savedBindings struct {
+	Keys    map[string]ebiten.Key `json:"keys"`
+	Buttons map[string]string     `json:"buttons"`
+}
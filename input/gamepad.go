@@ -0,0 +1,135 @@
+package input
+
+import (
+	"github.com/depsypher/gojoust/entity"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"log"
+	"slices"
+)
+
+// deadzone is how far the stick has to be pushed before it counts, so a worn stick resting
+// a little off center doesn't steer
+const deadzone = 0.3
+
+// noGamepad marks a player who hasn't got a gamepad
+const noGamepad ebiten.GamepadID = -1
+
+// Gamepad drives a player from a gamepad. With the standard layout the left stick steers
+// as well as the buttons bound to steering. Other gamepads steer with their first axis and
+// flap with their first button.
+type Gamepad struct {
+	ID    ebiten.GamepadID
+	Left  ebiten.StandardGamepadButton
+	Right ebiten.StandardGamepadButton
+	Flap  ebiten.StandardGamepadButton
+}
+
+func (g Gamepad) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	if !ebiten.IsStandardGamepadLayoutAvailable(g.ID) {
+		x := ebiten.GamepadAxisValue(g.ID, 0)
+		return entity.Intent{
+			Left:  x < -deadzone,
+			Right: x > deadzone,
+			Flap:  ebiten.IsGamepadButtonPressed(g.ID, ebiten.GamepadButton0),
+		}
+	}
+	x := ebiten.StandardGamepadAxisValue(g.ID, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	return entity.Intent{
+		Left:  x < -deadzone || g.Pressed(g.Left),
+		Right: x > deadzone || g.Pressed(g.Right),
+		Flap:  g.Pressed(g.Flap),
+	}
+}
+
+// Pressed is whether a button of the standard layout is held
+func (g Gamepad) Pressed(button ebiten.StandardGamepadButton) bool {
+	return ebiten.IsStandardGamepadButtonPressed(g.ID, button)
+}
+
+// Gamepads hands out gamepads to players as they're plugged in. A new gamepad goes to the
+// first player without one, and a player whose gamepad is pulled out gets the next one.
+type Gamepads struct {
+	players   []ebiten.GamepadID
+	connected []ebiten.GamepadID
+}
+
+func NewGamepads(players int) *Gamepads {
+	g := &Gamepads{players: make([]ebiten.GamepadID, players)}
+	for n := range g.players {
+		g.players[n] = noGamepad
+	}
+	return g
+}
+
+// Update notices gamepads that have been plugged in or pulled out since the last tick
+func (g *Gamepads) Update() {
+	g.connected = ebiten.AppendGamepadIDs(g.connected[:0])
+	for n, id := range g.players {
+		if id != noGamepad && !slices.Contains(g.connected, id) {
+			log.Printf("player %d's gamepad was unplugged", n+1)
+			g.players[n] = noGamepad
+		}
+	}
+	for _, id := range g.connected {
+		if slices.Contains(g.players, id) {
+			continue
+		}
+		if n := slices.Index(g.players, noGamepad); n >= 0 {
+			log.Printf("player %d is using gamepad %q", n+1, ebiten.GamepadName(id))
+			g.players[n] = id
+		}
+	}
+}
+
+// Player drives the numbered player with the buttons of pad from whichever gamepad they've
+// been given, if any
+func (g *Gamepads) Player(n int, pad Gamepad) entity.Controller {
+	return player{gamepads: g, number: n, pad: pad}
+}
+
+// Pressed is whether any player is holding the button of the standard layout
+func (g *Gamepads) Pressed(button ebiten.StandardGamepadButton) bool {
+	for _, id := range g.players {
+		if id != noGamepad && (Gamepad{ID: id}).Pressed(button) {
+			return true
+		}
+	}
+	return false
+}
+
+// JustPressed is whether any player pressed the button of the standard layout this tick
+func (g *Gamepads) JustPressed(button ebiten.StandardGamepadButton) bool {
+	for _, id := range g.players {
+		if id != noGamepad && inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendJustPressed appends the buttons of the standard layout any player pressed this tick
+func (g *Gamepads) AppendJustPressed(buttons []ebiten.StandardGamepadButton) []ebiten.StandardGamepadButton {
+	for _, id := range g.players {
+		if id != noGamepad {
+			buttons = inpututil.AppendJustPressedStandardGamepadButtons(id, buttons)
+		}
+	}
+	return buttons
+}
+
+type player struct {
+	gamepads *Gamepads
+	number   int
+	pad      Gamepad
+}
+
+func (p player) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	id := p.gamepads.players[p.number]
+	if id == noGamepad {
+		return entity.Intent{}
+	}
+	pad := p.pad
+	pad.ID = id
+	return pad.Intent(gs, m)
+}
@@ -0,0 +1,37 @@
+// Package input drives players from the devices ebiten can read
+package input
+
+import (
+	"github.com/depsypher/gojoust/entity"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Keyboard drives a player from three keys
+type Keyboard struct {
+	Left  ebiten.Key
+	Right ebiten.Key
+	Flap  ebiten.Key
+}
+
+func (k Keyboard) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	return entity.Intent{
+		Left:  ebiten.IsKeyPressed(k.Left),
+		Right: ebiten.IsKeyPressed(k.Right),
+		Flap:  ebiten.IsKeyPressed(k.Flap),
+	}
+}
+
+// Either drives a player from whichever of several controllers is being used, so a player
+// can play on the keyboard or their gamepad
+type Either []entity.Controller
+
+func (e Either) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	var result entity.Intent
+	for _, c := range e {
+		in := c.Intent(gs, m)
+		result.Left = result.Left || in.Left
+		result.Right = result.Right || in.Right
+		result.Flap = result.Flap || in.Flap
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package input
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"image"
+)
+
+// TouchZone is a part of the screen that works a control while it's touched
+type TouchZone int
+
+const (
+	TOUCH_LEFT  TouchZone = iota
+	TOUCH_RIGHT TouchZone = iota
+	TOUCH_FLAP  TouchZone = iota
+)
+
+// TouchZones are where each zone is on the screen, with steering under the left thumb and
+// flapping under the right. They run the full height of the screen so they're easy to find
+// without looking.
+var TouchZones = []image.Rectangle{
+	TOUCH_LEFT:  image.Rect(0, 0, app.ScreenWidth/4, app.ScreenHeight),
+	TOUCH_RIGHT: image.Rect(app.ScreenWidth/4, 0, app.ScreenWidth/2, app.ScreenHeight),
+	TOUCH_FLAP:  image.Rect(app.ScreenWidth/2, 0, app.ScreenWidth, app.ScreenHeight),
+}
+
+// Touch drives player one from a touch screen. Every finger counts, so one thumb can steer
+// while the other flaps.
+type Touch struct {
+	held  []bool
+	shown bool
+	ids   []ebiten.TouchID
+	keys  []ebiten.Key
+}
+
+func NewTouch() *Touch {
+	return &Touch{held: make([]bool, len(TouchZones))}
+}
+
+// Update notes which zones are being touched this tick. The zones show up once the screen
+// is touched, and go away again if a key gets pressed.
+func (t *Touch) Update() {
+	clear(t.held)
+	t.ids = ebiten.AppendTouchIDs(t.ids[:0])
+	for _, id := range t.ids {
+		p := image.Pt(ebiten.TouchPosition(id))
+		for zone, r := range TouchZones {
+			if p.In(r) {
+				t.held[zone] = true
+			}
+		}
+	}
+	if len(t.ids) > 0 {
+		t.shown = true
+	} else if t.keys = inpututil.AppendPressedKeys(t.keys[:0]); len(t.keys) > 0 {
+		t.shown = false
+	}
+}
+
+// Shown is whether the zones should be drawn, which is once someone is playing by touch
+func (t *Touch) Shown() bool {
+	return t.shown
+}
+
+// Held is whether the zone is being touched
+func (t *Touch) Held(zone TouchZone) bool {
+	return t.held[zone]
+}
+
+func (t *Touch) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	return entity.Intent{
+		Left:  t.held[TOUCH_LEFT],
+		Right: t.held[TOUCH_RIGHT],
+		Flap:  t.held[TOUCH_FLAP],
+	}
+}
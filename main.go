@@ -3,23 +3,40 @@ package main
 import (
 	_ "embed"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/depsypher/gojoust/app"
 	"github.com/depsypher/gojoust/assets/audio"
 	"github.com/depsypher/gojoust/entity"
+	"github.com/depsypher/gojoust/input"
+	"github.com/depsypher/gojoust/netplay"
+	"github.com/depsypher/gojoust/render"
+	"github.com/depsypher/gojoust/replay"
+	"github.com/depsypher/gojoust/scores"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 	"log"
-	"math/rand"
 	"time"
 )
 
 var (
 	ss *entity.Sheet
 
+	seed       = flag.Int64("seed", 0, "seed for gameplay randomness, picked from the current time if not given")
+	recordPath = flag.String("record", "", "record the first game to this replay file")
+	replayPath = flag.String("replay", "", "play back a recorded replay file")
+	levelPath  = flag.String("level", "", "play the arena from this level file instead of the classic one")
+	hostAddr   = flag.String("host", "", "host a two player game over the network, listening on this address")
+	joinAddr   = flag.String("join", "", "join a two player game hosted at this address")
+	coop       = flag.Bool("coop", false, "play co-op, where the players can't unhorse each other")
+
 	//go:embed app/crt.go
 	crt_go []byte
+
+	bindings = input.DefaultBindings()
+	pads     = input.NewGamepads(len(app.PlayerControls))
+	touch    = input.NewTouch()
 )
 
 func init() {
@@ -30,44 +47,47 @@ func init() {
 	ss = sheet
 }
 
-type toggleAction func()
+// debugX is where the god mode readouts go, clear of the frame rates
+const debugX = 96
 
 type Game struct {
-	inited bool
-	ss     entity.Sheet
-	state  *entity.GameState
-	screen *ebiten.Image
-	crt    *ebiten.Shader
+	inited    bool
+	seed      int64
+	level     *entity.Level
+	mode      entity.Mode
+	ss        entity.Sheet
+	state     *entity.GameState // the game being played
+	screens   []screen
+	sounds    audio.GameSounds
+	renderer  *render.Renderer
+	screen    *ebiten.Image
+	crt       *ebiten.Shader
+	recording *replay.Replay
+	playback  *replay.Playback
+	peer      *netplay.Peer
+	scores    *scores.Table
+	watched   bool // the game being played started out as a replay
+	recorded  bool // the first game has been saved to -record
+
+	// settings that last from one game to the next
+	soundOn bool
+	crtOn   bool
+	godMode bool
 }
 
 func (g *Game) init() {
 	defer func() {
 		g.inited = true
-		g.state = &entity.GameState{
-			Keys:      make(map[app.Control]bool),
-			GodMode:   false,
-			SoundOn:   true,
-			CrtOn:     true,
-			WaveStart: time.Now(),
-		}
-
-		g.state.Player = entity.MakePlayer(ss)
-		sp := app.SpawnPoints[1]
-		g.state.Player.SetPos(float64(sp[0]), float64(sp[1]))
-
-		g.state.Cliffs = []*entity.Cliff{
-			entity.MakeBottomCliff(ss.C1),
-			entity.MakeCliff(ss.C2, 105, 136), // mid-bottom
-			entity.MakeCliff(ss.C3, 83, 63),   // mid-top
-			entity.MakeCliff(ss.C4, -20, 52),  // top-left
-			entity.MakeCliff(ss.C5, 253, 52),  // top-right
-			entity.MakeCliff(ss.C6, -17, 114), // bottom-left
-			entity.MakeCliff(ss.C7, 257, 114), // bottom-right
-			entity.MakeCliff(ss.C8, 202, 106), // mid-right
+		g.renderer = render.NewRenderer(ss)
+		// a replay or network game goes straight into play
+		if g.playback != nil || g.peer != nil {
+			g.show(g.startGame())
+		} else {
+			g.show(newTitle())
 		}
 
 		var err error
-		g.state.Sounds, err = audio.LoadSounds()
+		g.sounds, err = audio.LoadSounds()
 		if err != nil {
 			errSound := errors.New("error loading sounds")
 			log.Fatal(errors.Join(errSound, err))
@@ -85,47 +105,104 @@ func (g *Game) Update() error {
 	if !g.inited {
 		g.init()
 	}
-	register(app.FlapButton, g.state.Keys)
-	register(app.LeftButton, g.state.Keys)
-	register(app.RightButton, g.state.Keys)
-	toggle(app.GodModeButton, g.state.Keys, func() {
-		g.state.Keys[app.GodModeButton] = true
-		g.state.GodMode = !g.state.GodMode
-	})
-	toggle(app.PauseButton, g.state.Keys, func() {
-		g.state.Keys[app.PauseButton] = true
-		g.state.Pause = !g.state.Pause
-	})
-	toggle(app.SoundButton, g.state.Keys, func() {
-		g.state.Keys[app.SoundButton] = true
-		g.state.SoundOn = !g.state.SoundOn
-	})
-	toggle(app.CrtButton, g.state.Keys, func() {
-		g.state.Keys[app.CrtButton] = true
-		g.state.CrtOn = !g.state.CrtOn
-	})
+	pads.Update()
+	touch.Update()
+	g.scores.Update()
+	return g.screens[len(g.screens)-1].Update(g)
+}
 
-	if time.Now().After(g.state.WaveStart.Add(time.Duration(3) * time.Second)) {
-		if len(g.state.Buzzards) < 3 && time.Now().After(g.state.NextSpawn) {
-			buzz := entity.MakeBuzzard(ss)
-			point := app.SpawnPoints[rand.Intn(len(app.SpawnPoints))]
-			buzz.SetPos(float64(point[0]), float64(point[1]))
-			if rand.Float32() < 0.5 {
-				buzz.FacingRight = false
-			}
-			g.state.Buzzards = append(g.state.Buzzards, buzz)
-			g.state.NextSpawn = time.Now().Add(time.Duration(1) * time.Second)
-		}
+// settings flips the settings whose buttons were pressed this tick
+func (g *Game) settings() {
+	if justPressed(app.SoundButton) {
+		g.soundOn = !g.soundOn
+	}
+	if justPressed(app.CrtButton) {
+		g.crtOn = !g.crtOn
+	}
+	if justPressed(app.GodModeButton) {
+		g.godMode = !g.godMode
+	}
+}
+
+// startGame starts a new game, the first on the seed the game was started with and the
+// rest on seeds of their own
+func (g *Game) startGame() screen {
+	log.Printf("using seed %d", g.seed)
+	g.state = entity.NewGameState(ss, g.level, g.seed, g.mode)
+	g.watched = g.playback != nil
+	g.plugIn()
+	// every game is recorded, to back up any high score made in it
+	g.recording = replay.New(g.seed, g.level, g.mode)
+	g.seed = time.Now().UnixNano()
+	return &playScreen{}
+}
+
+// endGame leaves the game being played. A network game is over for good, and a replay
+// has nothing more to show.
+func (g *Game) endGame() {
+	if err := g.saveRecording(); err != nil {
+		log.Printf("failed to save replay: %s", err)
+	}
+	g.sounds.StopSounds()
+	g.playback = nil
+	if g.peer != nil {
+		g.peer.Close()
+		g.peer = nil
+	}
+}
+
+// saveRecording saves the first game played to the file given with -record, as soon as it's
+// over or the window closes on it, so the replay goes with the seed it was started on
+func (g *Game) saveRecording() error {
+	if *recordPath == "" || g.recorded || g.recording == nil {
+		return nil
 	}
+	g.recorded = true
+	return g.recording.Save(*recordPath)
+}
 
-	if !g.state.Pause {
-		g.state.Player.Update(g.state)
-		for _, b := range g.state.Buzzards {
-			b.Update(g.state)
+// plugIn sets up what drives each player: the replay being watched, or the keyboard and
+// gamepads, with the other end of a network game taking over the player that isn't ours
+func (g *Game) plugIn() {
+	if g.playback != nil {
+		g.playback.Drive(g.state)
+		return
+	}
+	for n := range g.state.Controllers {
+		g.state.Controllers[n] = local(n)
+	}
+	if g.peer != nil {
+		for n := range g.state.Controllers {
+			g.state.Controllers[n] = g.peer.Remote()
 		}
+		g.state.Controllers[g.peer.Player()] = g.peer.Local(local(0))
 	}
+}
 
-	return nil
+// local drives the numbered player from the keyboard or their gamepad, or for player one
+// the touch screen too
+func local(n int) input.Either {
+	c := app.PlayerControls[n]
+	result := input.Either{bindings.Keyboard(c), pads.Player(n, bindings.Gamepad(c))}
+	if n == 0 {
+		result = append(result, touch)
+	}
+	return result
+}
+
+func (g *Game) playSounds(gs *entity.GameState, soundOn bool) {
+	for _, e := range gs.DrainSounds() {
+		switch e.Action {
+		case entity.PlaySound:
+			if err := g.sounds[e.Sound].Play(soundOn); err != nil {
+				log.Fatal("Error playing sound", err)
+			}
+		case entity.StopSound:
+			g.sounds[e.Sound].Stop()
+		case entity.StopAllSounds:
+			g.sounds.StopSounds()
+		}
+	}
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
@@ -136,26 +213,12 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		g.screen.Clear()
 	}
 
-	if g.state.GodMode {
-		ebitenutil.DebugPrint(g.screen, fmt.Sprintf("FPS: %3.2f\nTPS: %3.2f", ebiten.ActualFPS(), ebiten.ActualTPS()))
-		ebitenutil.DebugPrintAt(g.screen, g.state.Debug, 70, 0)
-		ebitenutil.DebugPrintAt(g.screen, fmt.Sprintf("%f", g.state.Player.Y), 70, 20)
-
-		for _, lane := range app.Lanes {
-			y := float32(lane)
-			vector.StrokeLine(g.screen, 0, y, app.ScreenWidth, y, 1, app.Yellow, false)
-		}
-	}
-	for _, cliff := range g.state.Cliffs {
-		cliff.Sprite.DrawSprite(g.screen)
+	for _, s := range g.screens {
+		s.Draw(g, g.screen)
 	}
-	for _, b := range g.state.Buzzards {
-		b.Draw(g.screen)
-	}
-
-	g.state.Player.Draw(g.screen)
+	g.renderer.Prune()
 
-	if g.state.CrtOn {
+	if g.crtOn {
 		op := &ebiten.DrawRectShaderOptions{}
 		op.Images[0] = g.screen
 		screen.DrawRectShader(w, h, g.crt, op)
@@ -165,36 +228,144 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 }
 
+// drawGame draws the arena and everything in it
+func (g *Game) drawGame(screen *ebiten.Image, gs *entity.GameState) {
+	if g.godMode {
+		debug := &render.TextOptions{Color: app.White}
+		g.renderer.DrawText(screen, fmt.Sprintf("FPS: %3.2f\nTPS: %3.2f", ebiten.ActualFPS(), ebiten.ActualTPS()), 0, 0, debug)
+		g.renderer.DrawText(screen, gs.Debug, debugX, 0, debug)
+		g.renderer.DrawText(screen, fmt.Sprintf("%f", gs.Players[0].Y), debugX, render.LineHeight, debug)
+
+		for _, lane := range gs.Lanes {
+			y := float32(lane)
+			vector.StrokeLine(screen, 0, y, app.ScreenWidth, y, 1, app.Yellow, false)
+		}
+	}
+	g.renderer.Follow(gs)
+	g.renderer.DrawLava(screen, gs)
+	for _, cliff := range gs.Cliffs {
+		g.renderer.DrawCliff(screen, cliff)
+	}
+	for _, b := range gs.Buzzards {
+		g.renderer.DrawSprite(screen, b.Sprite)
+	}
+	for _, e := range gs.Eggs {
+		g.renderer.DrawSprite(screen, e.Sprite)
+	}
+	for _, p := range gs.Pteros {
+		g.renderer.DrawSprite(screen, p.Sprite)
+	}
+
+	for _, p := range gs.Players {
+		if p.Alive {
+			g.renderer.DrawSprite(screen, p.Sprite)
+		}
+	}
+	g.renderer.DrawHUD(screen, gs)
+}
+
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return app.ScreenWidth, app.ScreenHeight
 }
 
+// pressed is whether the control is held on the keyboard or any player's gamepad
+func pressed(control app.Control) bool {
+	if ebiten.IsKeyPressed(bindings.Keys[control]) {
+		return true
+	}
+	button, ok := bindings.Buttons[control]
+	return ok && pads.Pressed(button)
+}
+
+// justPressed is whether the control was pressed this tick on the keyboard or any player's
+// gamepad
+func justPressed(control app.Control) bool {
+	if inpututil.IsKeyJustPressed(bindings.Keys[control]) {
+		return true
+	}
+	button, ok := bindings.Buttons[control]
+	return ok && pads.JustPressed(button)
+}
+
 func register(control app.Control, keys map[app.Control]bool) {
-	key := app.Controls[control]
-	if ebiten.IsKeyPressed(key) {
+	if pressed(control) {
 		keys[control] = true
 	} else {
 		delete(keys, control)
 	}
 }
 
-func toggle(control app.Control, keys map[app.Control]bool, action toggleAction) {
-	key := app.Controls[control]
-	if ebiten.IsKeyPressed(key) {
-		if !keys[control] {
-			keys[control] = true
-			action()
-		}
+func main() {
+	flag.Parse()
+	if b, err := input.LoadBindings(); err != nil {
+		log.Printf("using the default controls, failed to load saved ones: %s", err)
 	} else {
-		delete(keys, control)
+		bindings = b
+	}
+	if !flagGiven("seed") {
+		*seed = time.Now().UnixNano()
+	}
+
+	game := &Game{seed: *seed, level: entity.DefaultLevel(), soundOn: true, crtOn: true}
+	if *coop {
+		game.mode = entity.Coop
+	}
+	if *levelPath != "" {
+		l, err := entity.LoadLevel(*levelPath)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load level: %s", err))
+		}
+		game.level = l
+	}
+	var err error
+	if game.scores, err = scores.Load(ss, game.level); err != nil {
+		log.Printf("starting the high scores over, failed to load saved ones: %s", err)
+	}
+	if *replayPath != "" {
+		r, err := replay.Load(*replayPath)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load replay: %s", err))
+		}
+		if err := r.CheckLevel(game.level); err != nil {
+			log.Fatal(err)
+		}
+		game.seed = r.Seed
+		game.mode = r.Mode
+		game.playback = r.Play()
+	}
+	if *hostAddr != "" || *joinAddr != "" {
+		if *hostAddr != "" {
+			log.Printf("waiting for player two on %s", *hostAddr)
+			game.peer, err = netplay.Host(*hostAddr, game.seed, game.mode, game.level)
+		} else {
+			game.peer, err = netplay.Join(*joinAddr, game.level)
+		}
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to connect: %s", err))
+		}
+		defer game.peer.Close()
+		game.seed = game.peer.Seed
+		game.mode = game.peer.Mode
 	}
-}
 
-func main() {
 	ebiten.SetWindowSize(app.ScreenWidth*3, app.ScreenHeight*3)
 	ebiten.SetWindowTitle("GoJoust")
+	ebiten.SetTPS(app.TicksPerSecond)
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
+	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
+
+	if err := game.saveRecording(); err != nil {
+		log.Fatal(fmt.Errorf("failed to save replay: %s", err))
+	}
+}
+
+// flagGiven is whether the named flag was set on the command line, even to its default
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		given = given || f.Name == name
+	})
+	return given
 }
@@ -0,0 +1,182 @@
+// Package netplay plays a game between two machines over the network. Each end runs the
+// whole simulation, and every tick they swap what their own player did, so both stay in
+// lockstep without ever sending the state of the game.
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/depsypher/gojoust/entity"
+	"io"
+	"net"
+	"time"
+)
+
+var magic = []byte("GJNP")
+
+// timeout is how long to wait for the other end's move before giving up on them
+const timeout = 5 * time.Second
+
+// Peer is the connection to the other end of the game. The host plays player one and the
+// end that joins plays player two.
+type Peer struct {
+	conn net.Conn
+	host bool
+	Seed int64
+	Mode entity.Mode
+	err  error
+}
+
+// errLevel is why a game can't be played between ends that have different arenas
+var errLevel = errors.New("the other end is playing a different level")
+
+// Host waits for someone to join the game at addr, then tells them the seed and mode to play
+// and checks they're playing the same level
+func Host(addr string, seed int64, mode entity.Mode, level *entity.Level) (*Peer, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	p := &Peer{conn: conn, host: true, Seed: seed, Mode: mode}
+	header := binary.LittleEndian.AppendUint64(append([]byte{}, magic...), uint64(seed))
+	header = append(header, byte(mode))
+	header = binary.LittleEndian.AppendUint64(header, level.Hash())
+	if _, err := conn.Write(header); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// the other end answers with the level it has
+	reply := make([]byte, 8)
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(reply) != level.Hash() {
+		conn.Close()
+		return nil, errLevel
+	}
+	return p, nil
+}
+
+// Join connects to a game being hosted at addr, which has to be on the same level
+func Join(addr string, level *entity.Level) (*Peer, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(magic)+8+1+8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		conn.Close()
+		return nil, errors.New("not a game host")
+	}
+	seed := int64(binary.LittleEndian.Uint64(header[len(magic):]))
+	mode := entity.Mode(header[len(magic)+8])
+	hash := binary.LittleEndian.Uint64(header[len(magic)+8+1:])
+	// answer with our level either way, so the host knows not to wait on us
+	if _, err := conn.Write(binary.LittleEndian.AppendUint64(nil, level.Hash())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if hash != level.Hash() {
+		conn.Close()
+		return nil, errLevel
+	}
+	return &Peer{conn: conn, Seed: seed, Mode: mode}, nil
+}
+
+// Player is the number of the player at this end
+func (p *Peer) Player() int {
+	if p.host {
+		return 0
+	}
+	return 1
+}
+
+// Local wraps the controller of the player at this end, sending each intent over to the
+// other end as it's used
+func (p *Peer) Local(c entity.Controller) entity.Controller {
+	return &local{peer: p, controller: c}
+}
+
+// Remote plays the intents of the player at the other end as they arrive, waiting for them
+// when they're late, though not forever
+func (p *Peer) Remote() entity.Controller {
+	return &remote{peer: p}
+}
+
+// Err is why the game stopped hearing from the other end, if it has
+func (p *Peer) Err() error {
+	return p.err
+}
+
+func (p *Peer) Close() error {
+	return p.conn.Close()
+}
+
+type local struct {
+	peer       *Peer
+	controller entity.Controller
+}
+
+func (l *local) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	in := l.controller.Intent(gs, m)
+	if l.peer.err == nil {
+		_, l.peer.err = l.peer.conn.Write([]byte{encode(in)})
+	}
+	return in
+}
+
+type remote struct {
+	peer *Peer
+}
+
+func (r *remote) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	if r.peer.err != nil {
+		return entity.Intent{}
+	}
+	b := make([]byte, 1)
+	if err := r.peer.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		r.peer.err = err
+		return entity.Intent{}
+	}
+	if _, err := io.ReadFull(r.peer.conn, b); err != nil {
+		r.peer.err = err
+		return entity.Intent{}
+	}
+	return decode(b[0])
+}
+
+func encode(in entity.Intent) byte {
+	var b byte
+	if in.Left {
+		b |= 1
+	}
+	if in.Right {
+		b |= 2
+	}
+	if in.Flap {
+		b |= 4
+	}
+	return b
+}
+
+func decode(b byte) entity.Intent {
+	return entity.Intent{
+		Left:  b&1 != 0,
+		Right: b&2 != 0,
+		Flap:  b&4 != 0,
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/input"
+	"github.com/depsypher/gojoust/render"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"log"
+)
+
+// where things go on the options screen
+const (
+	optionsTop        = 26
+	optionsLineHeight = render.LineHeight
+	optionsKeyX       = 96
+	optionsButtonX    = 216
+	optionsMessageY   = 176
+)
+
+var bound = &render.TextOptions{Color: app.White}
+
+// the rows after the controls
+const (
+	resetRow = iota
+	doneRow  = iota
+)
+
+// options is the screen for rebinding the controls. The game stands still while it's up.
+type options struct {
+	bindings *input.Bindings
+	row      int
+	waiting  bool // for the key or button to bind to the control on the current row
+	message  string
+	keys     []ebiten.Key
+	buttons  []ebiten.StandardGamepadButton
+}
+
+func newOptions(b *input.Bindings) *options {
+	return &options{bindings: b}
+}
+
+func (o *options) rows() int {
+	return len(app.ControlNames) + doneRow + 1
+}
+
+func (o *options) Update(g *Game) error {
+	if o.waiting {
+		o.listen()
+		return nil
+	}
+	move, choose, back := menuInput()
+	o.row = (o.row + move + o.rows()) % o.rows()
+	switch {
+	case back:
+		g.closeOptions()
+	case choose && o.row-len(app.ControlNames) == resetRow:
+		*o.bindings = *input.DefaultBindings()
+		o.message = "controls reset"
+	case choose && o.row-len(app.ControlNames) == doneRow:
+		g.closeOptions()
+	case choose:
+		o.waiting = true
+		o.message = "press a key or button\nescape cancels"
+	}
+	return nil
+}
+
+// closeOptions saves the bindings and hands the players the new ones
+func (g *Game) closeOptions() {
+	g.pop()
+	if err := bindings.Save(); err != nil {
+		log.Printf("failed to save controls: %s", err)
+	}
+	if g.state != nil {
+		g.plugIn()
+	}
+}
+
+// listen binds the first key or gamepad button pressed to the control on the current row
+func (o *options) listen() {
+	control := app.Control(o.row)
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		o.waiting = false
+		o.message = ""
+		return
+	}
+	var other app.Control
+	var swapped bool
+	if o.keys = inpututil.AppendJustPressedKeys(o.keys[:0]); len(o.keys) > 0 {
+		other, swapped = o.bindings.BindKey(control, o.keys[0])
+	} else if o.buttons = pads.AppendJustPressed(o.buttons[:0]); len(o.buttons) > 0 {
+		other, swapped = o.bindings.BindButton(control, o.buttons[0])
+	} else {
+		return
+	}
+	o.waiting = false
+	o.message = ""
+	if swapped {
+		o.message = "swapped with " + other.String()
+	}
+}
+
+// Draw shades the game over with the controls and what they're bound to
+func (o *options) Draw(g *Game, screen *ebiten.Image) {
+	r := g.renderer
+	drawMenuBackground(g, screen, "options")
+	drawHighlight(screen, optionsTop+o.row*optionsLineHeight)
+
+	for c, name := range app.ControlNames {
+		y := float64(optionsTop + c*optionsLineHeight)
+		r.DrawText(screen, name, menuLeft, y, nil)
+		if o.waiting && c == o.row {
+			r.DrawText(screen, "?", optionsKeyX, y, nil)
+			continue
+		}
+		r.DrawText(screen, o.bindings.Keys[app.Control(c)].String(), optionsKeyX, y, bound)
+		r.DrawText(screen, input.ButtonNames[o.bindings.Buttons[app.Control(c)]], optionsButtonX, y, bound)
+	}
+	for i, name := range []string{"reset to defaults", "done"} {
+		r.DrawText(screen, name, menuLeft, float64(optionsTop+(len(app.ControlNames)+i)*optionsLineHeight), nil)
+	}
+	r.DrawText(screen, o.message, app.ScreenWidth/2, optionsMessageY, centered)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/hajimehoshi/ebiten/v2"
+	"log"
+)
+
+// playScreen is the game being played, or a replay of one being watched
+type playScreen struct{}
+
+func (p *playScreen) Update(g *Game) error {
+	if g.playback != nil && g.playback.Done() {
+		log.Println("replay finished")
+		g.playback = nil
+		g.plugIn()
+	}
+	g.settings()
+	// the other end of a network game can't be held up, so it plays straight through
+	switch {
+	case g.peer != nil:
+	case justPressed(app.PauseButton):
+		g.push(&pauseScreen{})
+		return nil
+	case justPressed(app.OptionsButton):
+		g.push(newOptions(bindings))
+		return nil
+	}
+	// the scroll button isn't sent over the network, so only one end would switch
+	if g.playback == nil && g.peer == nil {
+		register(app.ScrollButton, g.state.Keys)
+	}
+	if err := g.step(); err != nil {
+		return err
+	}
+	if g.state.GameOver {
+		g.show(&gameOverScreen{})
+	}
+	return nil
+}
+
+// step runs the game being played on a tick
+func (g *Game) step() error {
+	if g.peer != nil && g.peer.Err() != nil {
+		return fmt.Errorf("lost the other player: %w", g.peer.Err())
+	}
+	if g.playback != nil && !g.playback.Done() {
+		g.playback.Next(g.state.Keys)
+	}
+	g.state.Update()
+	if g.recording != nil {
+		g.recording.Record(g.state)
+	}
+	g.playSounds(g.state, g.soundOn)
+	return nil
+}
+
+func (p *playScreen) Draw(g *Game, dst *ebiten.Image) {
+	g.drawGame(dst, g.state)
+	g.renderer.DrawTouch(dst, touch)
+}
+
+// gameOverScreen lets the game run on a while after the last player is out, then has
+// anyone with a high score put their initials in
+type gameOverScreen struct {
+	ticks int
+}
+
+func (o *gameOverScreen) Update(g *Game) error {
+	o.ticks++
+	if err := g.step(); err != nil {
+		return err
+	}
+	// both ends of a network game have to stop on the same tick
+	skip := g.peer == nil && o.ticks >= app.GameOverSkipTicks && anyPressed()
+	if skip || o.ticks >= app.GameOverTicks {
+		g.endGame()
+		g.enterScores(g.state.Players)
+	}
+	return nil
+}
+
+func (o *gameOverScreen) Draw(g *Game, dst *ebiten.Image) {
+	g.drawGame(dst, g.state)
+}
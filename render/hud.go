@@ -0,0 +1,62 @@
+package render
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	"image/color"
+	"strconv"
+)
+
+// each player's score slot in the bottom cliff
+const (
+	scoreRight = 118
+	scoreY     = 184
+	livesX     = 121
+	maxLives   = 4
+	slotWidth  = 79
+)
+
+const (
+	bannerY          = 80
+	bannerLineHeight = 12
+)
+
+// panelColor is the score panel of the bottom cliff
+var panelColor = color.RGBA{R: 63, G: 36, B: 7, A: 255}
+
+var centered = &TextOptions{Align: ALIGN_CENTER}
+
+// DrawHUD draws the score bars over the bottom cliff, the banner at the start of each wave
+// and GAME OVER when it's all over. The bottom cliff scrolls away from under the score bars
+// when the camera follows the players, so then each bar gets a panel of its own.
+func (r *Renderer) DrawHUD(screen *ebiten.Image, gs *entity.GameState) {
+	life := r.ss.Life.Bounds()
+	for _, p := range gs.Players {
+		slot := p.Number * slotWidth
+		if gs.Scrolling {
+			end := slot + livesX + maxLives*(life.Dx()+1)
+			panel := image.Rect(end-slotWidth, scoreY-1, end, scoreY+life.Dy()+1)
+			screen.SubImage(panel).(*ebiten.Image).Fill(panelColor)
+		}
+		score := strconv.Itoa(p.Score)
+		r.DrawText(screen, score, float64(slot+scoreRight), scoreY, &TextOptions{Align: ALIGN_RIGHT})
+
+		for i := 0; i < min(p.Lives, maxLives); i++ {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(slot+livesX+i*(life.Dx()+1)), scoreY)
+			screen.DrawImage(r.staticImage(r.ss.Life), op)
+		}
+	}
+
+	if gs.Wave.ShowBanner(gs.Clock.Now()) {
+		for i, line := range gs.Wave.Banner() {
+			r.DrawText(screen, line, app.ScreenWidth/2, float64(bannerY+i*bannerLineHeight), centered)
+		}
+	}
+
+	if gs.GameOver {
+		r.DrawText(screen, "GAME OVER", app.ScreenWidth/2, app.ScreenHeight/2, centered)
+	}
+}
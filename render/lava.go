@@ -0,0 +1,21 @@
+package render
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	"image/color"
+)
+
+var lavaColor = color.RGBA{R: 191, G: 31, B: 0, A: 255}
+
+// DrawLava draws the Lava Troll's hand and the pit it comes up out of. It goes under the
+// cliffs so only the pit either side of the bottom cliff shows.
+func (r *Renderer) DrawLava(screen *ebiten.Image, gs *entity.GameState) {
+	if !gs.Troll.Hidden() {
+		r.DrawSprite(screen, gs.Troll.Sprite)
+	}
+	pit := image.Rect(0, int(gs.Level.LavaY), app.ScreenWidth, app.ScreenHeight)
+	screen.SubImage(pit).(*ebiten.Image).Fill(lavaColor)
+}
@@ -0,0 +1,142 @@
+package render
+
+import (
+	"github.com/depsypher/gojoust/entity"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+)
+
+type spriteImage struct {
+	source  *image.RGBA
+	version int
+	image   *ebiten.Image
+}
+
+// Renderer draws simulation sprites with ebiten, keeping a GPU copy of the image each
+// sprite is currently showing so it only gets uploaded when it changes, either for another
+// image or by being drawn on
+type Renderer struct {
+	ss      *entity.Sheet
+	sprites map[*entity.Sprite]*spriteImage
+	drawn   map[*entity.Sprite]bool
+	static  map[*image.RGBA]*ebiten.Image
+	camera  float64
+	seam    float64 // width of the world when it wraps around
+}
+
+func NewRenderer(ss *entity.Sheet) *Renderer {
+	return &Renderer{
+		ss:      ss,
+		sprites: make(map[*entity.Sprite]*spriteImage),
+		drawn:   make(map[*entity.Sprite]bool),
+		static:  make(map[*image.RGBA]*ebiten.Image),
+	}
+}
+
+// staticImage is the GPU copy of an image that never changes, like a font glyph
+func (r *Renderer) staticImage(src *image.RGBA) *ebiten.Image {
+	img, ok := r.static[src]
+	if !ok {
+		img = ebiten.NewImageFromImage(src)
+		r.static[src] = img
+	}
+	return img
+}
+
+// atlasImage is the part of the GPU copy of the atlas that src was cut from, so mounts never
+// have to be uploaded as they change
+func (r *Renderer) atlasImage(src *image.RGBA) *ebiten.Image {
+	img, ok := r.static[src]
+	if !ok {
+		img = r.staticImage(r.ss.Atlas.Image).SubImage(src.Rect).(*ebiten.Image)
+		r.static[src] = img
+	}
+	return img
+}
+
+func (r *Renderer) imageOf(s *entity.Sprite) *ebiten.Image {
+	src := s.Image()
+	if src == nil {
+		return nil
+	}
+	if r.ss.Atlas.Holds(src) {
+		return r.atlasImage(src)
+	}
+	r.drawn[s] = true
+
+	cached, ok := r.sprites[s]
+	if !ok {
+		cached = &spriteImage{source: src, version: s.Version(), image: ebiten.NewImageFromImage(src)}
+		r.sprites[s] = cached
+	} else if cached.source != src || cached.version != s.Version() {
+		if cached.image.Bounds().Size() == src.Bounds().Size() && src.Stride == 4*src.Bounds().Dx() {
+			cached.image.WritePixels(src.Pix)
+		} else {
+			cached.image.Deallocate()
+			cached.image = ebiten.NewImageFromImage(src)
+		}
+		cached.source = src
+		cached.version = s.Version()
+	}
+	return cached.image
+}
+
+// Follow moves the camera to where the game has it, so sprites are drawn relative to the
+// part of the world on screen
+func (r *Renderer) Follow(gs *entity.GameState) {
+	r.camera = gs.Camera
+	r.seam = 0
+	if gs.Wraps() {
+		r.seam = gs.Width()
+	}
+}
+
+// DrawSprite draws the sprite where it is in the world, relative to the camera. A sprite
+// straddling the seam of a world that wraps is drawn again on the other side, so it slides
+// off one edge and on at the other rather than vanishing.
+func (r *Renderer) DrawSprite(screen *ebiten.Image, s *entity.Sprite) {
+	img := r.imageOf(s)
+	if img == nil {
+		return
+	}
+	x, y := s.TopLeft()
+	r.draw(screen, img, x, y)
+	if r.seam == 0 || s.X < 0 || s.X >= r.seam {
+		// not in the world yet, or on its way out of it
+		return
+	}
+	if x < 0 {
+		r.draw(screen, img, x+r.seam, y)
+	} else if x+float64(s.Width) > r.seam {
+		r.draw(screen, img, x-r.seam, y)
+	}
+}
+
+// DrawCliff draws a ledge just the once. Ledges that run across the seam are already split
+// into a piece either side of it by the level.
+func (r *Renderer) DrawCliff(screen *ebiten.Image, c *entity.Cliff) {
+	img := r.imageOf(c.Sprite)
+	if img == nil {
+		return
+	}
+	x, y := c.TopLeft()
+	r.draw(screen, img, x, y)
+}
+
+func (r *Renderer) draw(screen *ebiten.Image, img *ebiten.Image, x, y float64) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(x-r.camera, y)
+	screen.DrawImage(img, op)
+}
+
+// Prune frees the images of sprites that haven't been drawn since the last call, such as
+// buzzards that have left the game
+func (r *Renderer) Prune() {
+	for s, cached := range r.sprites {
+		if !r.drawn[s] {
+			cached.image.Deallocate()
+			delete(r.sprites, s)
+		}
+	}
+	clear(r.drawn)
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+const glyphAdvance = 8
+
+// LineHeight is how far apart lines of text are drawn, before scaling
+const LineHeight = 10
+
+type Align int
+
+const (
+	ALIGN_LEFT   Align = iota
+	ALIGN_CENTER Align = iota
+	ALIGN_RIGHT  Align = iota
+)
+
+// TextOptions are how text gets drawn. The zero value draws it left aligned, at the font's
+// own size and in its own color.
+type TextOptions struct {
+	Color color.Color // nil for the font's own color
+	Align Align       // which part of each line goes at x
+	Scale float64     // 0 is the same as 1
+}
+
+// DrawText draws text in the arcade font with its top at y, each line aligned to x the way
+// op says. Characters the font doesn't have are left blank. op may be nil.
+func (r *Renderer) DrawText(screen *ebiten.Image, text string, x, y float64, op *TextOptions) {
+	if op == nil {
+		op = &TextOptions{}
+	}
+	scale := op.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	var cm colorm.ColorM
+	if op.Color != nil {
+		// keep the shape of the glyph, swapping its color for this one
+		cr, cg, cb, ca := op.Color.RGBA()
+		if ca > 0 {
+			cm.Scale(0, 0, 0, 1)
+			cm.Translate(float64(cr)/float64(ca), float64(cg)/float64(ca), float64(cb)/float64(ca), 0)
+			cm.Scale(1, 1, 1, float64(ca)/0xffff)
+		}
+	}
+
+	for l, line := range strings.Split(text, "\n") {
+		left := x
+		switch op.Align {
+		case ALIGN_CENTER:
+			left -= TextWidth(line) * scale / 2
+		case ALIGN_RIGHT:
+			left -= TextWidth(line) * scale
+		}
+		top := y + float64(l*LineHeight)*scale
+
+		for i, c := range []rune(line) {
+			glyph, ok := r.ss.Font[unicode.ToUpper(c)]
+			if !ok {
+				continue
+			}
+			var geoM ebiten.GeoM
+			geoM.Scale(scale, scale)
+			geoM.Translate(left+float64(i*glyphAdvance)*scale, top)
+			if op.Color == nil {
+				screen.DrawImage(r.staticImage(glyph), &ebiten.DrawImageOptions{GeoM: geoM})
+			} else {
+				colorm.DrawImage(screen, r.staticImage(glyph), cm, &colorm.DrawImageOptions{GeoM: geoM})
+			}
+		}
+	}
+}
+
+// TextWidth is how wide the widest line of text will be when drawn, before scaling
+func TextWidth(text string) float64 {
+	widest := 0
+	for _, line := range strings.Split(text, "\n") {
+		widest = max(widest, len([]rune(line)))
+	}
+	return float64(widest * glyphAdvance)
+}
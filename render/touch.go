@@ -0,0 +1,64 @@
+package render
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/input"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"image/color"
+)
+
+const (
+	touchButtonY      = 150 // clear of the score bars
+	touchArrowScale   = 3
+	touchFlapRadius   = 14
+	touchAlpha        = 0.25
+	touchHeldAlpha    = 0.6
+	touchDividerAlpha = 0.12
+)
+
+// scaleAlpha fades a color by alpha, which ebiten wants premultiplied
+func scaleAlpha(c color.RGBA, alpha float32) color.RGBA {
+	return color.RGBA{
+		R: uint8(float32(c.R) * alpha),
+		G: uint8(float32(c.G) * alpha),
+		B: uint8(float32(c.B) * alpha),
+		A: uint8(float32(c.A) * alpha),
+	}
+}
+
+// DrawTouch draws the touch zones over the game once someone is playing by touch, each lit up
+// while it's held
+func (r *Renderer) DrawTouch(screen *ebiten.Image, t *input.Touch) {
+	if !t.Shown() {
+		return
+	}
+	divider := scaleAlpha(app.White, touchDividerAlpha)
+	for zone, rect := range input.TouchZones {
+		alpha := float32(touchAlpha)
+		if t.Held(input.TouchZone(zone)) {
+			alpha = touchHeldAlpha
+		}
+		cx := float64(rect.Min.X+rect.Max.X) / 2
+		if rect.Min.X > 0 {
+			vector.StrokeLine(screen, float32(rect.Min.X), 0, float32(rect.Min.X), float32(rect.Max.Y), 1, divider, false)
+		}
+
+		if input.TouchZone(zone) == input.TOUCH_FLAP {
+			vector.StrokeCircle(screen, float32(cx), touchButtonY, touchFlapRadius, 2, scaleAlpha(app.White, alpha), false)
+			continue
+		}
+		// the font's arrow points left, so it's mirrored for right
+		arrow := r.staticImage(r.ss.Font['←'])
+		w := float64(arrow.Bounds().Dx() * touchArrowScale)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(touchArrowScale, touchArrowScale)
+		if input.TouchZone(zone) == input.TOUCH_RIGHT {
+			op.GeoM.Scale(-1, 1)
+			op.GeoM.Translate(w, 0)
+		}
+		op.GeoM.Translate(cx-w/2, touchButtonY-w/2)
+		op.ColorScale.ScaleAlpha(alpha)
+		screen.DrawImage(arrow, op)
+	}
+}
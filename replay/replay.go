@@ -0,0 +1,239 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"io"
+	"os"
+)
+
+const version = 3
+
+// MaxTicks is the longest replay that will be read, twelve hours of play, so a corrupt or
+// doctored file can't claim a run long enough to eat all the memory there is
+const MaxTicks = 12 * 60 * 60 * app.TicksPerSecond
+
+var (
+	magic = []byte("GJRP")
+
+	// gameControls are the buttons besides the players' that are fed back into the game on
+	// playback, the rest only change how the game is presented, so the viewer keeps them
+	gameControls = []app.Control{
+		app.ScrollButton,
+	}
+)
+
+// Controls is the set of controls held during a single tick, one bit per app.Control. Each
+// player's intent is stored in the bits of the controls app.PlayerControls gives them.
+type Controls uint16
+
+// ControlsOf is what the players wanted and the game buttons held on the tick just played
+func ControlsOf(gs *entity.GameState) Controls {
+	var c Controls
+	set := func(control app.Control, pressed bool) {
+		if pressed {
+			c |= 1 << control
+		}
+	}
+	for n, in := range gs.Intents {
+		controls := app.PlayerControls[n]
+		set(controls.Left, in.Left)
+		set(controls.Right, in.Right)
+		set(controls.Flap, in.Flap)
+	}
+	for _, control := range gameControls {
+		set(control, gs.Keys[control])
+	}
+	return c
+}
+
+func (c Controls) Pressed(control app.Control) bool {
+	return c&(1<<control) != 0
+}
+
+// intentOf is what the numbered player wanted on the tick
+func (c Controls) intentOf(n int) entity.Intent {
+	controls := app.PlayerControls[n]
+	return entity.Intent{
+		Left:  c.Pressed(controls.Left),
+		Right: c.Pressed(controls.Right),
+		Flap:  c.Pressed(controls.Flap),
+	}
+}
+
+// Replay is the seed a game was played with, the level and mode it was played in and the
+// controls held on every simulated tick, which is all that's needed to play the game again
+// exactly
+type Replay struct {
+	Seed  int64
+	Level uint64 // the level's Hash
+	Mode  entity.Mode
+	Ticks []Controls
+}
+
+func New(seed int64, level *entity.Level, mode entity.Mode) *Replay {
+	return &Replay{Seed: seed, Level: level.Hash(), Mode: mode}
+}
+
+// CheckLevel is an error unless the replay was recorded on the level, since it won't play
+// back the same anywhere else
+func (r *Replay) CheckLevel(level *entity.Level) error {
+	if r.Level != level.Hash() {
+		return errors.New("the replay was recorded on a different level")
+	}
+	return nil
+}
+
+// Record appends the controls of the tick the game just played
+func (r *Replay) Record(gs *entity.GameState) {
+	r.Ticks = append(r.Ticks, ControlsOf(gs))
+}
+
+// Write encodes the replay as a header followed by run-length encoded ticks, since
+// controls are usually held for many ticks in a row
+func (r *Replay) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(magic)
+	bw.WriteByte(version)
+	binary.Write(bw, binary.LittleEndian, r.Seed)
+	binary.Write(bw, binary.LittleEndian, r.Level)
+	bw.WriteByte(byte(r.Mode))
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	for i := 0; i < len(r.Ticks); {
+		run := 1
+		for i+run < len(r.Ticks) && r.Ticks[i+run] == r.Ticks[i] {
+			run++
+		}
+		bw.Write(buf[:binary.PutUvarint(buf, uint64(r.Ticks[i]))])
+		bw.Write(buf[:binary.PutUvarint(buf, uint64(run))])
+		i += run
+	}
+	return bw.Flush()
+}
+
+func Read(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return nil, errors.New("not a replay file")
+	}
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("unsupported replay version %d", header[len(magic)])
+	}
+
+	result := &Replay{}
+	if err := binary.Read(br, binary.LittleEndian, &result.Seed); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(br, binary.LittleEndian, &result.Level); err != nil {
+		return nil, err
+	}
+	mode, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	result.Mode = entity.Mode(mode)
+	if result.Mode != entity.Competitive && result.Mode != entity.Coop {
+		return nil, fmt.Errorf("replay is in unknown mode %d", mode)
+	}
+	for {
+		controls, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return nil, err
+		}
+		run, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if controls >= 1<<len(app.ControlNames) {
+			return nil, fmt.Errorf("replay holds unknown controls %#x", controls)
+		}
+		if run > uint64(MaxTicks-len(result.Ticks)) {
+			return nil, fmt.Errorf("replay is longer than %d ticks", MaxTicks)
+		}
+		for i := uint64(0); i < run; i++ {
+			result.Ticks = append(result.Ticks, Controls(controls))
+		}
+	}
+}
+
+func Load(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Playback steps through a replay one tick at a time
+type Playback struct {
+	replay  *Replay
+	tick    int
+	current Controls
+}
+
+func (r *Replay) Play() *Playback {
+	return &Playback{replay: r}
+}
+
+// Next moves on to the next recorded tick, setting the game buttons in keys to those held
+// on it, and reports whether there was a tick left to play
+func (p *Playback) Next(keys map[app.Control]bool) bool {
+	if p.Done() {
+		return false
+	}
+	p.current = p.replay.Ticks[p.tick]
+	for _, control := range gameControls {
+		if p.current.Pressed(control) {
+			keys[control] = true
+		} else {
+			delete(keys, control)
+		}
+	}
+	p.tick++
+	return true
+}
+
+// Drive hands every player slot of the game over to the replay
+func (p *Playback) Drive(gs *entity.GameState) {
+	for n := range gs.Controllers {
+		gs.Controllers[n] = &controller{playback: p, player: n}
+	}
+}
+
+// controller plays back what one player did on each tick
+type controller struct {
+	playback *Playback
+	player   int
+}
+
+func (c *controller) Intent(gs *entity.GameState, m *entity.MountSprite) entity.Intent {
+	return c.playback.current.intentOf(c.player)
+}
+
+func (p *Playback) Done() bool {
+	return p.tick >= len(p.replay.Ticks)
+}
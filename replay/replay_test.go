@@ -0,0 +1,111 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"strings"
+	"testing"
+)
+
+func newGame(t *testing.T, seed int64, mode entity.Mode) *entity.GameState {
+	t.Helper()
+	ss, err := entity.LoadSpriteSheet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entity.NewGameState(ss, entity.DefaultLevel(), seed, mode)
+}
+
+// summary is enough of the game to tell whether a replay played out the same
+func summary(gs *entity.GameState) string {
+	s := fmt.Sprintf("wave %d with %d buzzards and %d eggs", gs.Wave.Number, len(gs.Buzzards), len(gs.Eggs))
+	for _, p := range gs.Players {
+		s += fmt.Sprintf(", player %d at %.3f,%.3f scored %d with %d lives", p.Number+1, p.X, p.Y, p.Score, p.Lives)
+	}
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	const seed = 3
+	played := newGame(t, seed, entity.Coop)
+	for n := range played.Controllers {
+		played.Controllers[n] = entity.PlayerAI(seed + int64(n))
+	}
+	recording := New(seed, played.Level, played.Mode)
+	for i := 0; i < 30*app.TicksPerSecond; i++ {
+		played.Update()
+		recording.Record(played)
+	}
+
+	var buf bytes.Buffer
+	if err := recording.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Seed != seed || r.Mode != entity.Coop || len(r.Ticks) != len(recording.Ticks) {
+		t.Fatalf("read back seed %d mode %v and %d ticks, want %d, %v and %d", r.Seed, r.Mode, len(r.Ticks), seed, entity.Coop, len(recording.Ticks))
+	}
+	if err := r.CheckLevel(entity.DefaultLevel()); err != nil {
+		t.Fatal(err)
+	}
+
+	watched := newGame(t, r.Seed, r.Mode)
+	playback := r.Play()
+	playback.Drive(watched)
+	for playback.Next(watched.Keys) {
+		watched.Update()
+	}
+	if got, want := summary(watched), summary(played); got != want {
+		t.Errorf("the replay played out as %s, want %s", got, want)
+	}
+}
+
+func TestCheckLevel(t *testing.T) {
+	r := New(1, entity.DefaultLevel(), entity.Competitive)
+	other := entity.DefaultLevel()
+	other.Cliffs[1].Y--
+	if err := r.CheckLevel(other); err == nil {
+		t.Error("a replay played on a level with a ledge moved")
+	}
+}
+
+// header is the start of a replay file, up to the ticks
+func header(mode byte) []byte {
+	result := append([]byte{}, magic...)
+	result = append(result, version)
+	result = binary.LittleEndian.AppendUint64(result, 1)
+	result = binary.LittleEndian.AppendUint64(result, entity.DefaultLevel().Hash())
+	return append(result, mode)
+}
+
+func TestReadRejects(t *testing.T) {
+	run := func(controls, run uint64) []byte {
+		return binary.AppendUvarint(binary.AppendUvarint(header(0), controls), run)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not a replay", []byte("GIF89a....."), "not a replay"},
+		{"newer version", append(append([]byte{}, magic...), version+1), "unsupported replay version"},
+		{"unknown mode", header(9), "unknown mode"},
+		{"unknown controls", run(1<<len(app.ControlNames), 1), "unknown controls"},
+		{"too long", run(0, MaxTicks+1), "longer than"},
+		{"cut off", header(0)[:10], "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error about %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package scores keeps the high score tables, one for the best scores of all time and one
+// for the best of the day. Every score is saved with the replay of the game it was made in,
+// and a score its replay doesn't play back to gets thrown out, so there's nothing to be
+// gained by editing the saved table.
+package scores
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/depsypher/gojoust/replay"
+	"github.com/depsypher/gojoust/storage"
+	"io/fs"
+	"log"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// file is where the tables are saved between runs
+const file = "scores.json"
+
+// dayFormat is how the day a score was made is written down
+const dayFormat = time.DateOnly
+
+// yieldTicks is how often checking a replay lets the game have a turn. In the browser
+// goroutines take turns on one thread, so a long check would otherwise freeze the game.
+const yieldTicks = 1000
+
+// Entry is a score on one of the tables
+type Entry struct {
+	Initials string `json:"initials"`
+	Score    int    `json:"score"`
+	Player   int    `json:"player"` // which player in the replay made the score
+	Level    string `json:"level"`
+	Day      string `json:"day"`
+	Replay   []byte `json:"replay"`
+
+	// LevelHash is the Hash of the level the score was made on, so an edited copy of a level
+	// that kept its name gets a table of its own
+	LevelHash uint64 `json:"levelHash"`
+}
+
+// Table is every saved score for the level being played. Scores from other levels, or from
+// other layouts with the same name, are kept in the same file but left alone.
+type Table struct {
+	sheet    *entity.Sheet
+	level    *entity.Level
+	entries  []Entry // best first
+	others   []Entry // from other levels
+	rejected chan []Entry
+}
+
+// Load loads the saved scores and starts checking them against their replays. If they
+// can't be read an empty table comes back along with why.
+func Load(ss *entity.Sheet, level *entity.Level) (*Table, error) {
+	t := &Table{sheet: ss, level: level, rejected: make(chan []Entry)}
+	data, err := storage.Load(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	var saved []Entry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return t, err
+	}
+	for _, e := range saved {
+		if e.LevelHash == level.Hash() {
+			t.entries = append(t.entries, e)
+		} else {
+			t.others = append(t.others, e)
+		}
+	}
+	t.sort()
+	t.check(slices.Clone(t.entries))
+	return t, nil
+}
+
+// Update throws out any scores that have failed their check since the last tick
+func (t *Table) Update() {
+	select {
+	case bad := <-t.rejected:
+		t.entries = slices.DeleteFunc(t.entries, func(e Entry) bool {
+			return slices.ContainsFunc(bad, e.same)
+		})
+		if err := t.save(); err != nil {
+			log.Printf("failed to save high scores: %s", err)
+		}
+	default:
+	}
+}
+
+// AllTime is the table of the best scores ever
+func (t *Table) AllTime() []Entry {
+	return t.entries[:min(len(t.entries), app.HighScores)]
+}
+
+// Today is the table of the best scores of the day it is at now
+func (t *Table) Today(now time.Time) []Entry {
+	day := now.Format(dayFormat)
+	var result []Entry
+	for _, e := range t.entries {
+		if e.Day == day && len(result) < app.HighScores {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Qualifies is whether a score made now would make it onto either table
+func (t *Table) Qualifies(score int, now time.Time) bool {
+	return score > 0 && (beats(t.AllTime(), score) || beats(t.Today(now), score))
+}
+
+func beats(table []Entry, score int) bool {
+	return len(table) < app.HighScores || score > table[len(table)-1].Score
+}
+
+// Add puts the player's score on the tables with the replay of the game they made it in,
+// checks it against the replay, and saves the tables
+func (t *Table) Add(initials string, p *entity.Player, run *replay.Replay, now time.Time) error {
+	var buf bytes.Buffer
+	if err := run.Write(&buf); err != nil {
+		return err
+	}
+	e := Entry{
+		Initials: initials,
+		Score:    p.Score,
+		Player:   p.Number,
+		Level:    t.level.Name,
+		Day:      now.Format(dayFormat),
+		Replay:   buf.Bytes(),
+
+		LevelHash: t.level.Hash(),
+	}
+	t.entries = append(t.entries, e)
+	t.sort()
+	t.prune(now)
+	t.check([]Entry{e})
+	return t.save()
+}
+
+// sort puts the best scores first, with ties going to whoever got there first
+func (t *Table) sort() {
+	slices.SortStableFunc(t.entries, func(a, b Entry) int {
+		return b.Score - a.Score
+	})
+}
+
+// prune drops the scores that are on neither table any more
+func (t *Table) prune(now time.Time) {
+	keep := append(slices.Clone(t.AllTime()), t.Today(now)...)
+	t.entries = slices.DeleteFunc(t.entries, func(e Entry) bool {
+		return !slices.ContainsFunc(keep, e.same)
+	})
+}
+
+func (t *Table) save() error {
+	data, err := json.Marshal(append(slices.Clone(t.entries), t.others...))
+	if err != nil {
+		return err
+	}
+	return storage.Save(file, data)
+}
+
+// check plays the entries' replays in the background, handing the ones that don't add up
+// to Update
+func (t *Table) check(entries []Entry) {
+	go func() {
+		var bad []Entry
+		for _, e := range entries {
+			if err := t.verify(e); err != nil {
+				log.Printf("throwing out %s's score of %d: %s", e.Initials, e.Score, err)
+				bad = append(bad, e)
+			}
+		}
+		if len(bad) > 0 {
+			t.rejected <- bad
+		}
+	}()
+}
+
+// verify plays the entry's replay through and makes sure it comes to the same score. The
+// saved table can be edited, so a replay that crashes the game is only a bad score too.
+func (t *Table) verify(e Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the replay crashed the game: %v", r)
+		}
+	}()
+	run, err := replay.Read(bytes.NewReader(e.Replay))
+	if err != nil {
+		return err
+	}
+	if err := run.CheckLevel(t.level); err != nil {
+		return err
+	}
+	gs := entity.NewGameState(t.sheet, t.level, run.Seed, run.Mode)
+	playback := run.Play()
+	playback.Drive(gs)
+	// reading the replay has already kept it to a bounded length
+	for !gs.GameOver && playback.Next(gs.Keys) {
+		gs.Update()
+		gs.DrainSounds()
+		if gs.Clock.Now()%yieldTicks == 0 {
+			runtime.Gosched()
+		}
+	}
+	if e.Player >= len(gs.Players) {
+		return fmt.Errorf("there's no player %d in the replay", e.Player+1)
+	}
+	if score := gs.Players[e.Player].Score; score != e.Score {
+		return fmt.Errorf("the replay scores %d", score)
+	}
+	return nil
+}
+
+// same is whether two entries are the same score from the same game
+func (e Entry) same(other Entry) bool {
+	return e.Initials == other.Initials && e.Score == other.Score && e.Player == other.Player &&
+		e.Day == other.Day && bytes.Equal(e.Replay, other.Replay)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/render"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"image/color"
+)
+
+// screen is one of the screens the game moves between, like the title or the game itself.
+// They stack so a menu can come up over the game and go away again. Only the top one gets
+// updated, but they're all drawn from the bottom up.
+type screen interface {
+	Update(g *Game) error
+	Draw(g *Game, dst *ebiten.Image)
+}
+
+// show replaces every screen with s
+func (g *Game) show(s screen) {
+	clear(g.screens)
+	g.screens = append(g.screens[:0], s)
+}
+
+// push brings s up over the current screen
+func (g *Game) push(s screen) {
+	g.screens = append(g.screens, s)
+}
+
+// pop goes back to the screen under the current one
+func (g *Game) pop() {
+	g.screens[len(g.screens)-1] = nil
+	g.screens = g.screens[:len(g.screens)-1]
+}
+
+var (
+	menuShade     = color.RGBA{A: 224}
+	menuHighlight = color.RGBA{R: 96, G: 32, A: 255}
+	menuTitle     = &render.TextOptions{Align: render.ALIGN_CENTER, Color: app.White}
+	centered      = &render.TextOptions{Align: render.ALIGN_CENTER}
+)
+
+// where things go on menus
+const (
+	menuTitleY = 8
+	menuLeft   = 16
+)
+
+// menuInput reads the keys and buttons that steer every menu: the arrow keys, enter and
+// escape, or a gamepad's d-pad, A and B. A tap on the touch screen chooses. They can't be
+// rebound so there's always a way through.
+func menuInput() (move int, choose bool, back bool) {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) || pads.JustPressed(ebiten.StandardGamepadButtonLeftTop):
+		move = -1
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || pads.JustPressed(ebiten.StandardGamepadButtonLeftBottom):
+		move = 1
+	}
+	choose = inpututil.IsKeyJustPressed(ebiten.KeyEnter) || pads.JustPressed(ebiten.StandardGamepadButtonRightBottom) || touched()
+	back = inpututil.IsKeyJustPressed(ebiten.KeyEscape) || pads.JustPressed(ebiten.StandardGamepadButtonRightRight)
+	return move, choose, back
+}
+
+// anyPressed is whether anything at all was pressed this tick, whether a key, a gamepad
+// button or the touch screen
+func anyPressed() bool {
+	return len(inpututil.AppendJustPressedKeys(nil)) > 0 || len(pads.AppendJustPressed(nil)) > 0 || touched()
+}
+
+// touched is whether the touch screen was touched this tick
+func touched() bool {
+	return len(inpututil.AppendJustPressedTouchIDs(nil)) > 0
+}
+
+// blink is whether something blinking is showing on the given tick
+func blink(ticks int) bool {
+	return ticks/app.BlinkTicks%2 == 0
+}
+
+// drawMenuBackground shades out whatever's underneath and puts the title on top
+func drawMenuBackground(g *Game, dst *ebiten.Image, title string) {
+	vector.DrawFilledRect(dst, 0, 0, app.ScreenWidth, app.ScreenHeight, menuShade, false)
+	g.renderer.DrawText(dst, title, app.ScreenWidth/2, menuTitleY, menuTitle)
+}
+
+// drawHighlight marks the menu row with its text at y
+func drawHighlight(dst *ebiten.Image, y int) {
+	vector.DrawFilledRect(dst, menuLeft-4, float32(y-2), app.ScreenWidth-2*(menuLeft-4), render.LineHeight, menuHighlight, false)
+}
+
+// the choices on the pause menu
+const (
+	RESUME  = iota
+	OPTIONS = iota
+	QUIT    = iota
+)
+
+var pauseChoices = []string{
+	RESUME:  "resume",
+	OPTIONS: "options",
+	QUIT:    "quit to title",
+}
+
+const pauseTop = 80
+
+// pauseScreen holds the game still with a menu over it
+type pauseScreen struct {
+	row int
+}
+
+func (p *pauseScreen) Update(g *Game) error {
+	g.settings()
+	move, choose, back := menuInput()
+	p.row = (p.row + move + len(pauseChoices)) % len(pauseChoices)
+	switch {
+	case back || justPressed(app.PauseButton):
+		g.pop()
+	case choose && p.row == RESUME:
+		g.pop()
+	case choose && p.row == OPTIONS:
+		g.push(newOptions(bindings))
+	case choose && p.row == QUIT:
+		g.endGame()
+		g.show(newTitle())
+	}
+	return nil
+}
+
+func (p *pauseScreen) Draw(g *Game, dst *ebiten.Image) {
+	drawMenuBackground(g, dst, "paused")
+	drawHighlight(dst, pauseTop+p.row*render.LineHeight)
+	for i, choice := range pauseChoices {
+		g.renderer.DrawText(dst, choice, app.ScreenWidth/2, float64(pauseTop+i*render.LineHeight), centered)
+	}
+}
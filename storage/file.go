@@ -0,0 +1,37 @@
+//go:build !js
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Load reads what was last saved under name. It's an fs.ErrNotExist if nothing ever was.
+func Load(name string) ([]byte, error) {
+	path, err := pathOf(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// Save keeps data under name for the next time the game runs
+func Save(name string, data []byte) error {
+	path, err := pathOf(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func pathOf(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, app, name), nil
+}
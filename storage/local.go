@@ -0,0 +1,50 @@
+//go:build js
+
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"syscall/js"
+)
+
+// Load reads what was last saved under name. It's an fs.ErrNotExist if nothing ever was.
+func Load(name string) ([]byte, error) {
+	store, err := localStorage()
+	if err != nil {
+		return nil, err
+	}
+	v := store.Call("getItem", keyOf(name))
+	if v.IsNull() {
+		return nil, fs.ErrNotExist
+	}
+	return []byte(v.String()), nil
+}
+
+// Save keeps data under name for the next time the game runs
+func Save(name string, data []byte) (err error) {
+	store, err := localStorage()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// setItem throws when storage is full or turned off
+		if r := recover(); r != nil {
+			err = errors.New("browser storage refused the save")
+		}
+	}()
+	store.Call("setItem", keyOf(name), string(data))
+	return nil
+}
+
+func localStorage() (js.Value, error) {
+	store := js.Global().Get("localStorage")
+	if store.IsUndefined() || store.IsNull() {
+		return js.Value{}, errors.New("browser storage isn't available")
+	}
+	return store, nil
+}
+
+func keyOf(name string) string {
+	return app + "/" + name
+}
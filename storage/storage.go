@@ -0,0 +1,6 @@
+// Package storage keeps small files like settings between runs of the game. On the desktop
+// they go in the user's config directory, and in the browser they go in localStorage.
+package storage
+
+// app names the game's corner of wherever things get stored
+const app = "gojoust"
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"github.com/depsypher/gojoust/app"
+	"github.com/depsypher/gojoust/entity"
+	"github.com/depsypher/gojoust/render"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"time"
+)
+
+// where things go on the title screen
+const (
+	titleY       = 20
+	titleScale   = 3
+	scoresTitleY = 64
+	scoresTop    = 78
+	scoresLeft   = 96
+	scoresRight  = 204
+	startY       = 176
+	optionsHintY = 192
+)
+
+var (
+	titleText  = &render.TextOptions{Align: render.ALIGN_CENTER, Scale: titleScale}
+	scoresText = &render.TextOptions{Color: app.White}
+	pointsText = &render.TextOptions{Align: render.ALIGN_RIGHT, Color: app.White}
+	hintText   = &render.TextOptions{Align: render.ALIGN_CENTER, Color: app.Grey}
+)
+
+// titleScreen shows the high score tables until someone starts a game, or until it's been
+// up a while and the demo takes over
+type titleScreen struct {
+	ticks int
+}
+
+func newTitle() *titleScreen {
+	return &titleScreen{}
+}
+
+func (t *titleScreen) Update(g *Game) error {
+	t.ticks++
+	g.settings()
+	switch {
+	case justPressed(app.OptionsButton):
+		g.push(newOptions(bindings))
+	case started():
+		g.show(g.startGame())
+	case t.ticks >= app.TitleTicks:
+		g.show(newAttract(g))
+	}
+	return nil
+}
+
+// started is whether someone pressed a button that starts a game. Player two flapping gets
+// them straight into the game too.
+func started() bool {
+	return justPressed(app.FlapButton) || justPressed(app.P2FlapButton) ||
+		inpututil.IsKeyJustPressed(ebiten.KeyEnter) ||
+		pads.JustPressed(ebiten.StandardGamepadButtonCenterRight) || touched()
+}
+
+func (t *titleScreen) Draw(g *Game, dst *ebiten.Image) {
+	g.renderer.DrawText(dst, "gojoust", app.ScreenWidth/2, titleY, titleText)
+	t.drawScores(g, dst)
+	if blink(t.ticks) {
+		g.renderer.DrawText(dst, "press flap to start", app.ScreenWidth/2, startY, centered)
+	}
+	hint := fmt.Sprintf("%s for options", bindings.Keys[app.OptionsButton])
+	g.renderer.DrawText(dst, hint, app.ScreenWidth/2, optionsHintY, hintText)
+}
+
+// drawScores draws the table of today's best scores or the table of the best of all time,
+// taking turns
+func (t *titleScreen) drawScores(g *Game, dst *ebiten.Image) {
+	title, table := "today's greatest", g.scores.Today(time.Now())
+	if t.ticks/app.BoardTicks%2 == 1 {
+		title, table = "all time greatest", g.scores.AllTime()
+	}
+	g.renderer.DrawText(dst, title, app.ScreenWidth/2, scoresTitleY, centered)
+	for i, s := range table {
+		y := float64(scoresTop + i*render.LineHeight)
+		g.renderer.DrawText(dst, fmt.Sprintf("%d. %s", i+1, s.Initials), scoresLeft, y, scoresText)
+		g.renderer.DrawText(dst, fmt.Sprint(s.Score), scoresRight, y, pointsText)
+	}
+}
+
+// attractScreen runs a demo game with the computer flying for both players, like the
+// arcade cabinet does to draw a crowd. Pressing anything goes back to the title.
+type attractScreen struct {
+	state *entity.GameState
+	ticks int
+}
+
+func newAttract(g *Game) *attractScreen {
+	seed := time.Now().UnixNano()
+	gs := entity.NewGameState(ss, g.level, seed, g.mode)
+	for n := range gs.Controllers {
+		gs.Controllers[n] = entity.PlayerAI(seed + int64(n))
+	}
+	return &attractScreen{state: gs}
+}
+
+func (a *attractScreen) Update(g *Game) error {
+	a.ticks++
+	a.state.Update()
+	// the demo plays silently
+	g.playSounds(a.state, false)
+	if anyPressed() || a.state.GameOver || a.ticks >= app.AttractTicks {
+		g.sounds.StopSounds()
+		g.show(newTitle())
+	}
+	return nil
+}
+
+func (a *attractScreen) Draw(g *Game, dst *ebiten.Image) {
+	g.drawGame(dst, a.state)
+	if blink(a.ticks) {
+		g.renderer.DrawText(dst, "demo", app.ScreenWidth/2, menuTitleY, centered)
+	}
+}